@@ -0,0 +1,356 @@
+package goutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrValidation is an error of response when the request doesn't pass the binding or validation
+var ErrValidation = errors.New("request is not valid")
+
+const keyBoundRequest = "bound_request"
+
+// BindAndValidate is a function for bind the uri, query & body (JSON, XML, form or multipart by the content type) into T
+// and validate it by the Validation, the messages are translated into the language of Accept-Language header.
+// When it fails, the response 422 with ValidationErrors is sent, so the handler only needs to return
+//
+//	req, ok := goutil.BindAndValidate[CreateUser](c, &validation)
+//	if !ok {
+//		return
+//	}
+//
+// The rules must be written in the validate tag, the binding tag is validated by gin on every binding
+func BindAndValidate[T any](c *gin.Context, vt *Validation) (req T, ok bool) {
+
+	trans := vt.translator(c.GetHeader(HeaderLanguage))
+
+	validationErrors := bindRequest(c, &req, trans)
+	if !validationErrors.IsErrorExists() {
+		validationErrors = vt.validationStruct(req, trans)
+	}
+
+	if validationErrors.IsErrorExists() {
+		ResponseError(c, http.StatusUnprocessableEntity, ErrValidation, validationErrors)
+		c.Abort()
+		return req, false
+	}
+
+	return req, true
+}
+
+// BindRequest is a middleware for bind & validate the request into T, get the request by GetRequest in the next handler
+func BindRequest[T any](vt *Validation) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		req, ok := BindAndValidate[T](c, vt)
+		if !ok {
+			return
+		}
+
+		c.Set(keyBoundRequest, req)
+
+		// next handler
+		c.Next()
+	}
+}
+
+// GetRequest is a function for get the request which is bound by BindRequest
+func GetRequest[T any](c *gin.Context) T {
+
+	req, _ := c.MustGet(keyBoundRequest).(T)
+	return req
+}
+
+// bindRequest is a function for bind the request, the errors of binding are converted into ValidationErrors
+func bindRequest(c *gin.Context, req interface{}, trans ut.Translator) ValidationErrors {
+
+	if len(c.Params) > 0 {
+		if err := c.ShouldBindUri(req); err != nil {
+			values := map[string][]string{}
+			for _, param := range c.Params {
+				values[param.Key] = append(values[param.Key], param.Value)
+			}
+			return bindingErrors(c, req, "uri", values, err, trans)
+		}
+	}
+
+	if c.Request.URL.RawQuery != "" {
+		if err := c.ShouldBindQuery(req); err != nil {
+			return bindingErrors(c, req, "query", c.Request.URL.Query(), err, trans)
+		}
+	}
+
+	if c.Request.Method != http.MethodGet && c.Request.ContentLength != 0 {
+
+		// the body is kept by gin, so the path of JSON type error can be found and the next handler is able to bind it again
+		var err error
+		switch b := binding.Default(c.Request.Method, c.ContentType()).(type) {
+		case binding.BindingBody:
+			err = c.ShouldBindBodyWith(req, b)
+		default:
+			err = c.ShouldBindWith(req, b)
+		}
+
+		if err != nil {
+			return bindingErrors(c, req, "body", c.Request.Form, err, trans)
+		}
+	}
+
+	return ValidationErrors{}
+}
+
+// bindingErrors is a function for convert the error of binding into ValidationErrors, the type errors of JSON, uri, query & form
+// are mapped into the field and the other errors are mapped into the source (uri, query or body)
+func bindingErrors(c *gin.Context, req interface{}, source string, values map[string][]string, err error, trans ut.Translator) (validationError ValidationErrors) {
+
+	var validationErrors validator.ValidationErrors
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		return translateErrors(req, validationErrors, trans)
+
+	case errors.As(err, &syntaxError), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		validationError.Errors = append(validationError.Errors, ValidationError{
+			Field:   source,
+			Message: translateMessage(trans, "json_syntax", source),
+		})
+
+	case errors.As(err, &typeError):
+		var body []byte
+		if value, ok := c.Get(gin.BodyBytesKey); ok {
+			body, _ = value.([]byte)
+		}
+
+		// the field of type error doesn't have the index of array, so the path is found from the offset in the body
+		path, name := jsonPath(body, typeError.Offset)
+		if path == "" {
+			path = defaultString(strings.TrimPrefix(typeError.Field, "."), source)
+			name = path[strings.LastIndex(path, ".")+1:]
+		}
+
+		validationError.Errors = append(validationError.Errors, ValidationError{
+			Field:   path,
+			Message: translateMessage(trans, "field_type", name, typeName(typeError.Type)),
+		})
+
+	default:
+		field, fieldType, ok := formField(reflect.TypeOf(req), formTag(source), values, invalidValue(err))
+		if !ok {
+			validationError.Errors = append(validationError.Errors, ValidationError{
+				Field:   source,
+				Message: err.Error(),
+			})
+			break
+		}
+
+		validationError.Errors = append(validationError.Errors, ValidationError{
+			Field:   field,
+			Message: translateMessage(trans, "field_type", field, typeName(fieldType)),
+		})
+	}
+
+	return validationError
+}
+
+// jsonPath is a function for find the path (e.g. items[2].name) of value which ends at the offset of JSON,
+// it returns the path and the name of the last key
+func jsonPath(body []byte, offset int64) (path string, name string) {
+
+	type level struct {
+		array   bool
+		index   int
+		key     string
+		keyNext bool
+	}
+
+	// done is a function for move to the next value of array or the next key of object
+	var levels []level
+	done := func() {
+		if len(levels) > 0 {
+			top := &levels[len(levels)-1]
+			top.index++
+			top.keyNext = !top.array
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	for {
+
+		token, err := decoder.Token()
+		if err != nil {
+			return "", ""
+		}
+
+		delim, isDelim := token.(json.Delim)
+		if isDelim && (delim == '}' || delim == ']') {
+			levels = levels[:len(levels)-1]
+			done()
+			continue
+		}
+
+		if len(levels) > 0 && levels[len(levels)-1].keyNext {
+			levels[len(levels)-1].key, _ = token.(string)
+			levels[len(levels)-1].keyNext = false
+			continue
+		}
+
+		if decoder.InputOffset() >= offset {
+			break
+		}
+
+		if isDelim {
+			levels = append(levels, level{array: delim == '[', keyNext: delim == '{'})
+			continue
+		}
+
+		done()
+	}
+
+	for _, level := range levels {
+
+		if level.array {
+			path += "[" + strconv.Itoa(level.index) + "]"
+			continue
+		}
+
+		if path != "" {
+			path += "."
+		}
+		path += level.key
+		name = level.key
+	}
+
+	return path, name
+}
+
+// formTag is a function for get the tag of struct which is used by gin for bind the source
+func formTag(source string) string {
+	if source == "uri" {
+		return "uri"
+	}
+
+	return "form"
+}
+
+// invalidValue is a function for get the value which isn't able to parse by gin
+func invalidValue(err error) string {
+
+	var numError *strconv.NumError
+	var timeError *time.ParseError
+
+	switch {
+	case errors.As(err, &numError):
+		return numError.Num
+	case errors.As(err, &timeError):
+		return timeError.Value
+	}
+
+	return ""
+}
+
+// formField is a function for find the field which has the invalid value, the struct is walked like the form binding of gin,
+// it returns the name of field in the tag and the type of field
+func formField(t reflect.Type, tag string, values map[string][]string, value string) (string, reflect.Type, bool) {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || value == "" {
+		return "", nil, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+			fieldType = fieldType.Elem()
+		}
+
+		// the struct without tag is bound by its fields
+		if name == "" && fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+			if nested, nestedType, ok := formField(fieldType, tag, values, value); ok {
+				return nested, nestedType, true
+			}
+			continue
+		}
+
+		name = defaultString(name, field.Name)
+		if fieldType.Kind() != reflect.String && slices.Contains(values[name], value) {
+			return name, fieldType, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// typeName is a function for get the name of type in JSON, so the message doesn't contain the type of Go
+func typeName(t reflect.Type) string {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map:
+		return "object"
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return "time"
+		}
+		return "object"
+	}
+
+	return t.String()
+}
+
+// translateMessage is a function for translate the custom message, the message of english is used
+// when the translations aren't registered by RegisterTranslation
+func translateMessage(trans ut.Translator, key string, params ...string) string {
+
+	if message, err := trans.T(key, params...); err == nil {
+		return message
+	}
+
+	message := customMessages[LocaleEnglish][key]
+	for i, param := range params {
+		message = strings.Replace(message, "{"+strconv.Itoa(i)+"}", param, 1)
+	}
+
+	return message
+}
@@ -0,0 +1,132 @@
+package goutil_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	goutil "github.com/muhammadrivaldy/go-util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CreateAccount struct {
+	ShopID  int    `uri:"shop_id" validate:"min=1"`
+	DryRun  bool   `form:"dry_run"`
+	Limit   int    `form:"limit" validate:"max=100"`
+	Name    string `json:"name" validate:"required"`
+	Age     int    `json:"age" validate:"min=17"`
+	Address struct {
+		PostalCode string `json:"postal_code" validate:"required,postcode_idn"`
+	} `json:"address"`
+	Items []struct {
+		Name string `json:"name"`
+	} `json:"items,omitempty"`
+}
+
+type bindingResponse struct {
+	Code    int                     `json:"code"`
+	Message string                  `json:"message"`
+	Data    goutil.ValidationErrors `json:"data"`
+}
+
+// go test -v -run=^TestBindAndValidate$
+func TestBindAndValidate(t *testing.T) {
+	validation, err := goutil.NewValidation()
+	require.NoError(t, err)
+	require.NoError(t, validation.RegisterValidation())
+	require.NoError(t, validation.RegisterTranslation())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/shops/:shop_id/accounts", func(c *gin.Context) {
+		req, ok := goutil.BindAndValidate[CreateAccount](c, &validation)
+		if !ok {
+			return
+		}
+
+		goutil.ResponseOK(c, http.StatusCreated, req)
+	})
+	router.PUT("/shops/:shop_id/accounts", goutil.BindRequest[CreateAccount](&validation), func(c *gin.Context) {
+		goutil.ResponseOK(c, http.StatusCreated, goutil.GetRequest[CreateAccount](c).Name)
+	})
+
+	send := func(method, path, body, language string) (*httptest.ResponseRecorder, bindingResponse) {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept-Language", language)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		var response bindingResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder, response
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		recorder, _ := send(http.MethodPost, "/shops/7/accounts?dry_run=true", `{"name":"rivaldy","age":20,"address":{"postal_code":"40115"}}`, "")
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.JSONEq(t, `{"code":201,"message":"success","data":{"ShopID":7,"DryRun":true,"Limit":0,"name":"rivaldy","age":20,"address":{"postal_code":"40115"}}}`, recorder.Body.String())
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		recorder, response := send(http.MethodPost, "/shops/7/accounts", `{"age":10,"address":{"postal_code":"123"}}`, "id-ID,id;q=0.9")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, goutil.ErrValidation.Error(), response.Message)
+		assert.ElementsMatch(t, []goutil.ValidationError{
+			{Field: "name", Message: "name wajib diisi"},
+			{Field: "age", Message: "age harus 17 atau lebih besar"},
+			{Field: "address.postal_code", Message: "postal_code harus berupa kode pos Indonesia yang valid"},
+		}, response.Data.Errors)
+	})
+
+	t.Run("uri & query", func(t *testing.T) {
+		// the fields without json tag are named by the uri & form tag, like the type errors
+		recorder, response := send(http.MethodPost, "/shops/0/accounts?limit=500", `{"name":"rivaldy","age":20,"address":{"postal_code":"40115"}}`, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.ElementsMatch(t, []goutil.ValidationError{
+			{Field: "shop_id", Message: "shop_id must be 1 or greater"},
+			{Field: "limit", Message: "limit must be 100 or less"},
+		}, response.Data.Errors)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		recorder, response := send(http.MethodPost, "/shops/7/accounts", `{"name":"rivaldy","age":"twenty"}`, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, []goutil.ValidationError{{Field: "age", Message: "age must be of type number"}}, response.Data.Errors)
+
+		// the path contains the index of array
+		recorder, response = send(http.MethodPost, "/shops/7/accounts", `{"name":"rivaldy","items":[{"name":"a"},{"name":"b"},{"name":3}]}`, "id")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, []goutil.ValidationError{{Field: "items[2].name", Message: "name harus bertipe string"}}, response.Data.Errors)
+
+		// the value of uri & query which can't be parsed is mapped into the field
+		recorder, response = send(http.MethodPost, "/shops/abc/accounts", `{"name":"rivaldy"}`, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, []goutil.ValidationError{{Field: "shop_id", Message: "shop_id must be of type number"}}, response.Data.Errors)
+
+		recorder, response = send(http.MethodPost, "/shops/7/accounts?dry_run=maybe", `{"name":"rivaldy"}`, "id")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, []goutil.ValidationError{{Field: "dry_run", Message: "dry_run harus bertipe boolean"}}, response.Data.Errors)
+	})
+
+	t.Run("syntax", func(t *testing.T) {
+		recorder, response := send(http.MethodPost, "/shops/7/accounts", `{"name":`, "id")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, []goutil.ValidationError{{Field: "body", Message: "body harus berupa JSON yang valid"}}, response.Data.Errors)
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		recorder, _ := send(http.MethodPut, "/shops/7/accounts", `{"name":"rivaldy","age":20,"address":{"postal_code":"40115"}}`, "")
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.JSONEq(t, `{"code":201,"message":"success","data":"rivaldy"}`, recorder.Body.String())
+
+		recorder, response := send(http.MethodPut, "/shops/7/accounts", `{"name":"rivaldy","age":20}`, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, []goutil.ValidationError{{Field: "address.postal_code", Message: "postal_code is a required field"}}, response.Data.Errors)
+	})
+}
@@ -2,18 +2,35 @@ package goutil
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
 
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Key string
 
 const (
-	KeyRequestID Key = "request_id"
-	KeyMethod    Key = "method"
-	KeyEndpoint  Key = "endpoint"
-	KeyToken     Key = "token"
+	KeyRequestID   Key = "request_id"
+	KeyMethod      Key = "method"
+	KeyEndpoint    Key = "endpoint"
+	KeyToken       Key = "token"
+	KeyTraceParent Key = "traceparent"
+	KeyTraceState  Key = "tracestate"
+	KeyLanguage    Key = "language"
+)
+
+const (
+	HeaderRequestID   = "X-Request-ID"
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+	HeaderLanguage    = "Accept-Language"
 )
 
 func (k Key) String() string {
@@ -21,9 +38,11 @@ func (k Key) String() string {
 }
 
 type Context struct {
-	RequestID string
-	Method    string
-	Endpoint  string
+	RequestID   string
+	Method      string
+	Endpoint    string
+	TraceParent string
+	TraceState  string
 }
 
 // SetContext is a function for set value on context
@@ -32,11 +51,37 @@ func SetContext(c *gin.Context) {
 	// declare variable context.Context
 	ctx := c.Request.Context()
 
+	// adopt the request id from the caller, if it doesn't exist or it isn't valid we'll create it,
+	// the request id is echoed in the response, so the caller is able to correlate it
+	requestID := requestid.Get(c)
+	if requestID == "" {
+		requestID = c.GetHeader(HeaderRequestID)
+	}
+
+	if !regexRequestID.MatchString(requestID) {
+		requestID = uuid.New().String()
+	}
+	c.Header(HeaderRequestID, requestID)
+
 	// set up value (request id, method & endpoint) to context
-	ctx = context.WithValue(ctx, KeyRequestID, requestid.Get(c))
+	ctx = context.WithValue(ctx, KeyRequestID, requestID)
 	ctx = context.WithValue(ctx, KeyMethod, c.Request.Method)
 	ctx = context.WithValue(ctx, KeyEndpoint, c.Request.URL.RequestURI())
 
+	// set up value of trace context (W3C) from the caller, the tracestate is ignored without the valid traceparent
+	if traceParent := c.GetHeader(HeaderTraceParent); regexTraceParent.MatchString(traceParent) {
+		ctx = context.WithValue(ctx, KeyTraceParent, traceParent)
+
+		if traceState := c.GetHeader(HeaderTraceState); len(traceState) <= maxTraceState && regexTraceState.MatchString(traceState) {
+			ctx = context.WithValue(ctx, KeyTraceState, traceState)
+		}
+	}
+
+	// set up the language of caller, it's used for translate the message of validation
+	if language := c.GetHeader(HeaderLanguage); language != "" {
+		ctx = context.WithValue(ctx, KeyLanguage, language)
+	}
+
 	// set up context.Context to gin.Context
 	c.Set("context", ctx)
 
@@ -71,7 +116,75 @@ func getContext(ctx context.Context) (c Context) {
 			c.Endpoint = ctx.Value(KeyEndpoint).(string)
 		}
 
+		if ctx.Value(KeyTraceParent) != nil {
+			c.TraceParent = ctx.Value(KeyTraceParent).(string)
+		}
+
+		if ctx.Value(KeyTraceState) != nil {
+			c.TraceState = ctx.Value(KeyTraceState).(string)
+		}
+
 	}
 
 	return
 }
+
+// propagateContext is a function for forward the request id & trace context (W3C) to the outgoing request,
+// the headers which already set by the caller won't be replaced
+func propagateContext(ctx context.Context, request *http.Request) {
+
+	res := getContext(ctx)
+
+	// the span of opentelemetry has priority over the trace context from the caller,
+	// without the span the outgoing request is a new child of the caller, so it gets the new parent id
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		res.TraceParent = fmt.Sprintf("00-%s-%s-%s", spanContext.TraceID(), spanContext.SpanID(), spanContext.TraceFlags())
+		res.TraceState = spanContext.TraceState().String()
+	} else if res.TraceParent != "" {
+		res.TraceParent = childTraceParent(res.TraceParent)
+		if res.TraceParent == "" {
+			res.TraceState = ""
+		}
+	}
+
+	headers := map[string]string{
+		HeaderRequestID:   res.RequestID,
+		HeaderTraceParent: res.TraceParent,
+		HeaderTraceState:  res.TraceState,
+	}
+
+	for key, value := range headers {
+		if value != "" && request.Header.Get(key) == "" {
+			request.Header.Set(key, value)
+		}
+	}
+}
+
+var (
+	// regexRequestID is the request id which is adopted from the caller, it's limited because it's logged & forwarded
+	regexRequestID   = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+	regexTraceParent = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+	regexTraceState  = regexp.MustCompile(`^[\x20-\x7e]+$`)
+)
+
+// maxTraceState is the maximum length of tracestate, 32 members of 256 characters (W3C)
+const maxTraceState = 8192
+
+// childTraceParent is a function for create the traceparent of child span, the trace id & flags are kept
+// and the parent id is replaced by the new span id, it returns empty string when the traceparent isn't valid
+func childTraceParent(traceParent string) string {
+
+	matches := regexTraceParent.FindStringSubmatch(traceParent)
+	if matches == nil || matches[1] == "ff" || matches[2] == "00000000000000000000000000000000" {
+		return ""
+	}
+
+	var spanID [8]byte
+	for spanID == [8]byte{} {
+		if _, err := rand.Read(spanID[:]); err != nil {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("00-%s-%s-%s", matches[2], hex.EncodeToString(spanID[:]), matches[4])
+}
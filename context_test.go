@@ -0,0 +1,62 @@
+package goutil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	goutil "github.com/muhammadrivaldy/go-util"
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v -run=^TestSetContext$
+func TestSetContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var values map[goutil.Key]interface{}
+	router := gin.New()
+	router.GET("/users", goutil.SetContext, func(c *gin.Context) {
+		ctx := goutil.ParseContext(c)
+		values = map[goutil.Key]interface{}{}
+		for _, key := range []goutil.Key{goutil.KeyRequestID, goutil.KeyTraceParent, goutil.KeyTraceState} {
+			values[key] = ctx.Value(key)
+		}
+	})
+
+	send := func(headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/users", nil)
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the request id & trace context of caller are adopted, and the request id is echoed
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	recorder := send(map[string]string{
+		goutil.HeaderRequestID:   "request-1",
+		goutil.HeaderTraceParent: traceParent,
+		goutil.HeaderTraceState:  "vendor=value",
+	})
+	assert.Equal(t, "request-1", recorder.Header().Get(goutil.HeaderRequestID))
+	assert.Equal(t, "request-1", values[goutil.KeyRequestID])
+	assert.Equal(t, traceParent, values[goutil.KeyTraceParent])
+	assert.Equal(t, "vendor=value", values[goutil.KeyTraceState])
+
+	// the invalid headers aren't adopted
+	recorder = send(map[string]string{
+		goutil.HeaderRequestID:   strings.Repeat("a", 200),
+		goutil.HeaderTraceParent: "invalid",
+		goutil.HeaderTraceState:  "vendor=value",
+	})
+	requestID := recorder.Header().Get(goutil.HeaderRequestID)
+	assert.Len(t, requestID, 36)
+	assert.Equal(t, requestID, values[goutil.KeyRequestID])
+	assert.Nil(t, values[goutil.KeyTraceParent])
+	assert.Nil(t, values[goutil.KeyTraceState])
+}
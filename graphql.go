@@ -0,0 +1,101 @@
+package goutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLRequest is a payload of GraphQL
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	// Headers is the additional headers of request
+	Headers map[string]string `json:"-"`
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is an error from the errors array of GraphQL response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e GraphQLError) Error() string {
+
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+
+	return fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// GraphQLErrors is an error of GraphQL response, use errors.As for getting the detail of errors
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// GraphQL is a function for send the GraphQL request to the path and decode the data into T,
+// the data is still returned with GraphQLErrors when the response is partially succeed
+func GraphQL[T any](ctx context.Context, client *RESTful, path string, req GraphQLRequest) (data T, err error) {
+
+	var errorResponse graphQLResponse
+	response, _, err := Do[graphQLResponse](ctx, client, RequestPayload{
+		Path:          path,
+		Method:        http.MethodPost,
+		ContentType:   ContentTypeJSON,
+		Payload:       req,
+		ErrorResponse: &errorResponse,
+		Headers:       req.Headers,
+	})
+
+	// some servers send the errors with the status code 4xx
+	var httpError *HTTPError
+	if errors.As(err, &httpError) && len(errorResponse.Errors) > 0 {
+		return data, fmt.Errorf("%w: %w", errorResponse.Errors, err)
+	}
+
+	if err != nil {
+		return data, err
+	}
+
+	if len(response.Data) > 0 && string(response.Data) != "null" {
+		if err = json.Unmarshal(response.Data, &data); err != nil {
+			return data, err
+		}
+	}
+
+	if len(response.Errors) > 0 {
+		return data, response.Errors
+	}
+
+	return data, nil
+}
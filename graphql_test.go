@@ -0,0 +1,51 @@
+package goutil_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	goutil "github.com/muhammadrivaldy/go-util"
+	"github.com/muhammadrivaldy/go-util/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v -run=^TestGraphQL$
+func TestGraphQL(t *testing.T) {
+	server := resttest.NewServer(t)
+	server.On(http.MethodPost, "/graphql").
+		ExpectJSON(map[string]interface{}{
+			"query":     "query($id: ID!) { user(id: $id) { name } }",
+			"variables": map[string]interface{}{"id": "1"},
+		}).
+		Reply(http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"user": map[string]string{"name": "go-util"}},
+			"errors": []map[string]interface{}{{
+				"message":    "email is hidden",
+				"path":       []interface{}{"user", "email"},
+				"extensions": map[string]string{"code": "FORBIDDEN"},
+			}},
+		})
+
+	type data struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	result, err := goutil.GraphQL[data](context.Background(), restful, "/graphql", goutil.GraphQLRequest{
+		Query:     "query($id: ID!) { user(id: $id) { name } }",
+		Variables: map[string]interface{}{"id": "1"},
+	})
+
+	// the data is still returned with the errors
+	assert.Equal(t, "go-util", result.User.Name)
+
+	var graphQLErrors goutil.GraphQLErrors
+	if assert.True(t, errors.As(err, &graphQLErrors)) {
+		assert.Equal(t, "FORBIDDEN", graphQLErrors[0].Extensions["code"])
+		assert.Equal(t, "graphql: email is hidden (path: user.email)", err.Error())
+	}
+}
@@ -2,18 +2,17 @@ package goutil
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
-	"fmt"
 	"io"
 	"mime"
-	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 )
 
 type contentType string
@@ -23,6 +22,9 @@ const (
 	ContentTypeFormURLEncoded    contentType = "application/x-www-form-urlencoded"
 	ContentTypeTextHTML          contentType = "text/html"
 	ContentTypeMultipartFormData contentType = "multipart/form-data"
+	ContentTypeXML               contentType = "application/xml"
+	ContentTypeTextPlain         contentType = "text/plain"
+	ContentTypeOctetStream       contentType = "application/octet-stream"
 )
 
 type RequestPayload struct {
@@ -33,7 +35,11 @@ type RequestPayload struct {
 	Payload     interface{}
 	PayloadFile interface{}
 	Response    interface{}
-	Headers     map[string]string
+	// ErrorResponse is filled by the body of response when the status code is greater or equal than 400
+	ErrorResponse interface{}
+	Headers       map[string]string
+	// Timeout is a limit for the whole request, including the retries
+	Timeout time.Duration
 }
 
 type BasicAuthPayload struct {
@@ -41,7 +47,11 @@ type BasicAuthPayload struct {
 	Method   string
 	Payload  BasicAuth
 	Response interface{}
-	Headers  map[string]string
+	// ErrorResponse is filled by the body of response when the status code is greater or equal than 400
+	ErrorResponse interface{}
+	Headers       map[string]string
+	// Timeout is a limit for the whole request, including the retries
+	Timeout time.Duration
 }
 
 type BasicAuth struct {
@@ -50,15 +60,62 @@ type BasicAuth struct {
 }
 
 type RESTful struct {
-	retry   int
-	baseURL string
+	baseURL      string
+	client       *http.Client
+	retryPolicy  RetryPolicy
+	interceptors []Interceptor
+	// clientOptions are applied on the copy of client after all options,
+	// so the result doesn't depend on the order of options and the client of caller isn't changed
+	clientOptions []func(client *http.Client)
+	// transport is the base transport of WithTransport, transportOptions are applied on its clone
+	transport        http.RoundTripper
+	transportOptions []func(transport *http.Transport)
+	// err is the error of options, it's returned by every request
+	err error
 }
 
-func NewRESTful(baseURL string, retry int) *RESTful {
-	return &RESTful{
-		retry:   retry,
-		baseURL: baseURL,
+// RESTfulOption is a function for configure the RESTful client
+type RESTfulOption func(r *RESTful)
+
+// WithTimeout is a function for set up the timeout of every attempt on the client level
+func WithTimeout(timeout time.Duration) RESTfulOption {
+	return func(r *RESTful) {
+		r.clientOptions = append(r.clientOptions, func(client *http.Client) {
+			client.Timeout = timeout
+		})
+	}
+}
+
+// WithHTTPClient is a function for replace the default http.Client, the client is copied
+// so the client of caller isn't changed by the other options
+func WithHTTPClient(client *http.Client) RESTfulOption {
+	return func(r *RESTful) {
+		if client != nil {
+			r.client = client
+		}
+	}
+}
+
+func NewRESTful(baseURL string, retry int, opts ...RESTfulOption) *RESTful {
+
+	r := &RESTful{
+		baseURL:     baseURL,
+		client:      &http.Client{},
+		retryPolicy: DefaultRetryPolicy(retry),
 	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	client := *r.client
+	for _, opt := range r.clientOptions {
+		opt(&client)
+	}
+	r.err = r.applyTransport(&client)
+	r.client = &client
+
+	return r
 }
 
 func setQueryParam(baseURL, pathURL string, queryParam map[string]string) (urlRequest string, err error) {
@@ -78,221 +135,361 @@ func setQueryParam(baseURL, pathURL string, queryParam map[string]string) (urlRe
 	return urls.String(), err
 }
 
+// withTimeout is a function for limit the context of a whole request, including the retries
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
 func (r *RESTful) Request(req RequestPayload) (statusCode int, err error) {
+	return r.RequestWithContext(context.Background(), req)
+}
+
+// RequestWithContext is a function for send request, the deadline & cancellation of context is honoured by every retry
+func (r *RESTful) RequestWithContext(ctx context.Context, req RequestPayload) (statusCode int, err error) {
+
+	ctx, cancel := withTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	response, meta, err := r.send(ctx, req.ErrorResponse, func(ctx context.Context) (*http.Request, error) {
+		return r.newRequest(ctx, req)
+	})
+	if err != nil {
+		return meta.statusCode(), err
+	}
+
+	// getting content type
+	// we don't need handle this error because isn't important
+	contentType, _, _ := mime.ParseMediaType(response.Header.Get("content-type"))
+
+	// setup response
+	err = decodeResponse(response, contentType, req.Response)
+	return response.StatusCode, err
+}
+
+// send is a function for send the request with the retries, the response which the status code is greater or equal
+// than 400 is returned as HTTPError. The meta is nil when there is no response, the caller must close the body of response
+func (r *RESTful) send(ctx context.Context, errorResponse interface{}, newRequest func(ctx context.Context) (*http.Request, error)) (response *http.Response, meta *ResponseMeta, err error) {
+
+	response, attempts, err := r.do(ctx, newRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta = &ResponseMeta{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Attempts:   attempts,
+	}
 
-	for i := 0; i < r.retry; i++ {
+	// the retry still failed, so we'll return the error
+	if response.StatusCode >= http.StatusBadRequest {
+		httpError := newHTTPError(response, attempts, errorResponse)
+		meta.Body = httpError.Body
+		return nil, meta, httpError
+	}
+
+	return response, meta, nil
+}
+
+// do is a function for send the request built by newRequest and retry it based on the retry policy,
+// the caller must close the body of response
+func (r *RESTful) do(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (response *http.Response, attempts int, err error) {
+	return r.doWithPolicy(ctx, r.retryPolicy, newRequest)
+}
+
+// doWithPolicy is a function for send the request with the retry policy, it's used when the caller retries by itself
+func (r *RESTful) doWithPolicy(ctx context.Context, policy RetryPolicy, newRequest func(ctx context.Context) (*http.Request, error)) (response *http.Response, attempts int, err error) {
+
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+
+	if policy.MaxAttempts < 1 {
+		return nil, attempts, errors.New("retry need to setup greater than 0")
+	}
+
+	for attempts = 1; ; attempts++ {
 
-		var client = &http.Client{}
-		var request *http.Request
-		urlRequest, err := setQueryParam(r.baseURL, req.Path, req.QueryParam)
+		// stop the retry when the context is done
+		if err = ctx.Err(); err != nil {
+			return nil, attempts, err
+		}
+
+		request, err := newRequest(ctx)
 		if err != nil {
-			return statusCode, err
+			return nil, attempts, err
 		}
 
-		// setup request
-		if req.ContentType == ContentTypeJSON {
+		propagateContext(ctx, request)
 
-			payload := &strings.Reader{}
+		// action request
+		response, err = r.roundTrip(request)
 
-			if req.Payload != nil {
+		// the interceptor may reject the request before it's sent, so the body must be closed
+		if err != nil && request.Body != nil {
+			request.Body.Close()
+		}
 
-				payloadJSON, err := json.Marshal(req.Payload)
-				if err != nil {
-					return statusCode, err
-				}
+		lastAttempt := attempts >= policy.MaxAttempts || !isReplayable(request)
 
-				payload = strings.NewReader(string(payloadJSON))
+		if err != nil {
+			if lastAttempt || !policy.isRetryableError(ctx, isIdempotent(request), err) {
+				return nil, attempts, err
 			}
 
-			request, err = http.NewRequest(req.Method, urlRequest, payload)
-			if err != nil {
-				return statusCode, err
+			if err = sleepContext(ctx, policy.backoff(attempts, nil)); err != nil {
+				return nil, attempts, err
 			}
 
-			request.Header.Add("content-type", string(ContentTypeJSON))
+			continue
+		}
+
+		// the response will be returned when it isn't able to retry
+		if lastAttempt || !policy.isRetryableStatus(response.StatusCode) {
+			return response, attempts, nil
+		}
 
-		} else if req.ContentType == ContentTypeFormURLEncoded {
+		delay := policy.backoff(attempts, response)
+		drainBody(response)
 
-			if req.Payload == nil {
-				return statusCode, errors.New("payload is nil")
-			}
+		if err = sleepContext(ctx, delay); err != nil {
+			return nil, attempts, err
+		}
+	}
+}
+
+// newRequest is a function for build the http.Request from RequestPayload
+func (r *RESTful) newRequest(ctx context.Context, req RequestPayload) (request *http.Request, err error) {
+
+	urlRequest, err := setQueryParam(r.baseURL, req.Path, req.QueryParam)
+	if err != nil {
+		return nil, err
+	}
 
-			if reflect.TypeOf(req.Payload).String() == "url.Values" {
+	// setup request
+	if req.ContentType == ContentTypeJSON {
 
-				values := req.Payload.(url.Values)
-				request, err = http.NewRequest(req.Method, urlRequest, strings.NewReader(values.Encode()))
-				if err != nil {
-					return statusCode, err
-				}
+		payload := &strings.Reader{}
 
-				request.Header.Add("Content-Type", string(ContentTypeFormURLEncoded))
+		if req.Payload != nil {
 
-			} else {
-				return statusCode, errors.New("payload isn't url.Values")
+			payloadJSON, err := json.Marshal(req.Payload)
+			if err != nil {
+				return nil, err
 			}
 
-		} else if req.ContentType == ContentTypeMultipartFormData {
+			payload = strings.NewReader(string(payloadJSON))
+		}
 
-			body := &bytes.Buffer{}
-			writer := multipart.NewWriter(body)
+		request, err = http.NewRequestWithContext(ctx, req.Method, urlRequest, payload)
+		if err != nil {
+			return nil, err
+		}
 
-			if req.Payload == nil && req.PayloadFile == nil {
-				return statusCode, errors.New("payload is nil")
-			}
+		request.Header.Add("content-type", string(ContentTypeJSON))
 
-			if req.Payload != nil && reflect.TypeOf(req.Payload).String() == "url.Values" {
-				values := req.Payload.(url.Values)
-				for key, value := range values {
-					for _, i := range value {
-						writer.WriteField(key, i)
-					}
-				}
-			}
+	} else if req.ContentType == ContentTypeFormURLEncoded {
 
-			if req.PayloadFile != nil && reflect.TypeOf(req.PayloadFile).String() == "url.Values" {
-				values := req.PayloadFile.(url.Values)
-				for key, value := range values {
-					for _, i := range value {
-						file, err := os.Open(i)
-						if err != nil {
-							return statusCode, err
-						}
-						defer file.Close()
-
-						part, err := writer.CreateFormFile(key, filepath.Base(i))
-						if err != nil {
-							return statusCode, err
-						}
-
-						io.Copy(part, file)
-					}
-				}
-			}
+		if req.Payload == nil {
+			return nil, errors.New("payload is nil")
+		}
 
-			writer.Close()
+		if reflect.TypeOf(req.Payload).String() == "url.Values" {
 
-			request, err = http.NewRequest(req.Method, urlRequest, body)
+			values := req.Payload.(url.Values)
+			request, err = http.NewRequestWithContext(ctx, req.Method, urlRequest, strings.NewReader(values.Encode()))
 			if err != nil {
-				return statusCode, err
+				return nil, err
 			}
 
-			request.Header.Add("Content-Type", writer.FormDataContentType())
+			request.Header.Add("Content-Type", string(ContentTypeFormURLEncoded))
 
 		} else {
-			return statusCode, errors.New("you must to choice the content type")
+			return nil, errors.New("payload isn't url.Values")
 		}
 
-		// action request
-		setHeader(request, req.Headers)
-		response, err := client.Do(request)
+	} else if req.ContentType == ContentTypeMultipartFormData {
+
+		if req.Payload == nil && req.PayloadFile == nil {
+			return nil, errors.New("payload is nil")
+		}
+
+		var fields url.Values
+		if req.Payload != nil && reflect.TypeOf(req.Payload).String() == "url.Values" {
+			fields = req.Payload.(url.Values)
+		}
+
+		files, err := multipartFiles(req.PayloadFile)
 		if err != nil {
-			return statusCode, err
+			return nil, err
 		}
 
-		// getting content type
-		// we don't need handle this error because isn't important
-		contentType, _, _ := mime.ParseMediaType(response.Header.Get("content-type"))
-
-		// if statuscode isn't ok, we'll retry the request
-		// but, if the retry still failed we'll return the error
-		if ((i + 1) == r.retry) && response.StatusCode >= http.StatusBadRequest {
-			errorMessage := fmt.Sprintf("Status code: %d / %s", response.StatusCode, http.StatusText(response.StatusCode))
-			if contentType == string(ContentTypeJSON) {
-				resBytes, _ := io.ReadAll(response.Body)
-				errorMessage = fmt.Sprintf("%s, Response: %s", errorMessage, string(resBytes))
-			}
+		multipartBody := newMultipartBody(fields, files)
+		body, err := multipartBody.open()
+		if err != nil {
+			return nil, err
+		}
 
-			return response.StatusCode, errors.New(errorMessage)
-		} else if response.StatusCode >= http.StatusBadRequest {
-			continue
+		request, err = http.NewRequestWithContext(ctx, req.Method, urlRequest, body)
+		if err != nil {
+			body.Close()
+			return nil, err
 		}
 
-		// setup response
-		if contentType == string(ContentTypeJSON) {
+		if multipartBody.replayable() {
+			request.GetBody = multipartBody.open
+		}
 
-			if req.Response != nil {
+		request.Header.Add("Content-Type", multipartBody.contentType())
 
-				resBytes, err := io.ReadAll(response.Body)
-				if err != nil {
-					return response.StatusCode, err
-				}
+	} else if req.ContentType == ContentTypeXML {
 
-				if err = json.Unmarshal(resBytes, req.Response); err != nil {
-					return response.StatusCode, err
-				}
+		payload, ok := rawPayload(req.Payload)
+		if !ok {
+
+			payloadXML, err := xml.Marshal(req.Payload)
+			if err != nil {
+				return nil, err
 			}
+
+			payload = bytes.NewReader(payloadXML)
 		}
 
-		return response.StatusCode, err
+		request, err = newRawRequest(ctx, req.Method, urlRequest, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Add("Content-Type", string(ContentTypeXML))
+
+	} else if req.ContentType != "" {
+
+		// the other content types are sent as it is, e.g. text/plain or application/octet-stream
+		payload, ok := rawPayload(req.Payload)
+		if !ok {
+			return nil, errors.New("payload isn't []byte, string or io.Reader")
+		}
+
+		request, err = newRawRequest(ctx, req.Method, urlRequest, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Add("Content-Type", string(req.ContentType))
+
+	} else {
+		return nil, errors.New("you must to choice the content type")
 	}
 
-	return statusCode, errors.New("retry need to setup greater than 0")
+	setHeader(request, req.Headers)
+
+	return request, nil
 }
 
-func (r *RESTful) RequestBasicAuth(req BasicAuthPayload) (statusCode int, err error) {
+// decodeResponse is a function for decode the body of response into the target
+func decodeResponse(response *http.Response, contentType string, target interface{}) error {
 
-	for i := 0; i < r.retry; i++ {
+	defer response.Body.Close()
 
-		var client = &http.Client{}
-		var request *http.Request
-		var urlRequest = r.baseURL + req.Path
+	if target == nil {
+		return nil
+	}
 
-		if req.Payload.Username == "" {
-			return statusCode, errors.New("username must be filled")
-		}
+	resBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
 
-		if req.Payload.Password == "" {
-			return statusCode, errors.New("password must be filled")
-		}
+	return decodeBody(contentType, resBytes, target)
+}
 
-		request, err = http.NewRequest(req.Method, urlRequest, nil)
-		if err != nil {
-			return statusCode, err
-		}
+// decodeBody is a function for decode the body based on the content type into the target,
+// the target *string & *[]byte receive the body as it is
+func decodeBody(contentType string, body []byte, target interface{}) error {
 
-		setHeader(request, req.Headers)
-		request.SetBasicAuth(req.Payload.Username, req.Payload.Password)
+	if target == nil || len(body) == 0 {
+		return nil
+	}
 
-		// action request
-		response, err := client.Do(request)
-		if err != nil {
-			return statusCode, err
-		}
+	switch t := target.(type) {
+	case *string:
+		*t = string(body)
+		return nil
+	case *[]byte:
+		*t = body
+		return nil
+	}
 
-		// if statuscode isn't ok, we'll retry the request
-		// but, if the retry still failed we'll return the error
-		if (i + 1) == r.retry {
-			return response.StatusCode, errors.New(http.StatusText(response.StatusCode))
-		} else if response.StatusCode >= http.StatusBadRequest {
-			continue
-		}
+	if isJSON(contentType) {
+		return json.Unmarshal(body, target)
+	}
 
-		// getting content type
-		contentType, _, err := mime.ParseMediaType(response.Header.Get("content-type"))
-		if err != nil {
-			return response.StatusCode, err
-		}
+	if isXML(contentType) {
+		return xml.Unmarshal(body, target)
+	}
+
+	return nil
+}
 
-		// setup response
-		if contentType == string(ContentTypeJSON) {
+// drainBody is a function for discard & close the body, so the connection can be reused
+func drainBody(response *http.Response) {
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+}
 
-			if req.Response != nil {
+func (r *RESTful) RequestBasicAuth(req BasicAuthPayload) (statusCode int, err error) {
+	return r.RequestBasicAuthWithContext(context.Background(), req)
+}
 
-				resBytes, err := io.ReadAll(response.Body)
-				if err != nil {
-					return response.StatusCode, err
-				}
+// RequestBasicAuthWithContext is a function for send request with basic auth, the deadline & cancellation of context is honoured by every retry
+func (r *RESTful) RequestBasicAuthWithContext(ctx context.Context, req BasicAuthPayload) (statusCode int, err error) {
 
-				if err = json.Unmarshal(resBytes, req.Response); err != nil {
-					return response.StatusCode, err
-				}
-			}
+	ctx, cancel := withTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	if req.Payload.Username == "" {
+		return statusCode, errors.New("username must be filled")
+	}
+
+	if req.Payload.Password == "" {
+		return statusCode, errors.New("password must be filled")
+	}
+
+	response, meta, err := r.send(ctx, req.ErrorResponse, func(ctx context.Context) (*http.Request, error) {
+
+		request, err := http.NewRequestWithContext(ctx, req.Method, r.baseURL+req.Path, nil)
+		if err != nil {
+			return nil, err
 		}
 
+		setHeader(request, req.Headers)
+		request.SetBasicAuth(req.Payload.Username, req.Payload.Password)
+
+		return request, nil
+	})
+	if err != nil {
+		return meta.statusCode(), err
+	}
+
+	// getting content type
+	contentType, _, err := mime.ParseMediaType(response.Header.Get("content-type"))
+	if err != nil {
+		response.Body.Close()
 		return response.StatusCode, err
 	}
 
-	return statusCode, errors.New("retry need to setup greater than 0")
+	// setup response
+	err = decodeResponse(response, contentType, req.Response)
+	return response.StatusCode, err
 }
 
 func setHeader(request *http.Request, headers map[string]string) {
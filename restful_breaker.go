@@ -0,0 +1,273 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is an error when the request is rejected by the circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitHalfOpen
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("unknown state: %d", s)
+	}
+}
+
+// CircuitBreakerConfig is a configuration of CircuitBreaker
+type CircuitBreakerConfig struct {
+	// Name is the name of circuit breaker, it's used by OnStateChange
+	Name string
+	// MinRequests is the minimum of requests in the interval before the failure ratio is evaluated
+	MinRequests int
+	// FailureRatio is the ratio (0 - 1) of failed requests for open the circuit
+	FailureRatio float64
+	// Interval is the period for clear the counts when the circuit is closed, the default is 60 seconds
+	// and the negative value means never
+	Interval time.Duration
+	// CoolDown is the period of open state before the circuit becomes half-open
+	CoolDown time.Duration
+	// HalfOpenMaxRequests is the maximum of requests allowed when the circuit is half-open
+	HalfOpenMaxRequests int
+	// IsFailure is a function for decide the result of request is failed or not
+	IsFailure func(response *http.Response, err error) bool
+	// OnStateChange is called every time the state of circuit is changed, don't call the circuit breaker inside it
+	OnStateChange func(name string, from, to CircuitState)
+}
+
+// CircuitBreaker is a circuit breaker which can be shared by the RESTful clients of the same base URL
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu         sync.Mutex
+	state      CircuitState
+	generation uint64
+	requests   int
+	failures   int
+	expiry     time.Time
+}
+
+// NewCircuitBreaker is a function for create the circuit breaker, the empty config will be filled by the default value
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = 0.5
+	}
+
+	if config.Interval == 0 {
+		config.Interval = 60 * time.Second
+	}
+
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+
+	if config.HalfOpenMaxRequests <= 0 {
+		config.HalfOpenMaxRequests = 1
+	}
+
+	if config.IsFailure == nil {
+		config.IsFailure = isCircuitFailure
+	}
+
+	cb := &CircuitBreaker{config: config}
+	cb.newGeneration(time.Now())
+
+	return cb
+}
+
+// WithCircuitBreaker is a function for protect the client with the circuit breaker
+func WithCircuitBreaker(cb *CircuitBreaker) RESTfulOption {
+	return func(r *RESTful) {
+		r.Use(cb.Interceptor())
+	}
+}
+
+// TeleStateChange is a function for notify the state change of circuit breaker through TeleService
+func TeleStateChange(tele TeleService) func(name string, from, to CircuitState) {
+	return func(name string, from, to CircuitState) {
+		msg := fmt.Sprintf("circuit breaker %s changed from %s to %s", name, from, to)
+		go tele.SendError(context.Background(), "circuit-breaker", 0, msg)
+	}
+}
+
+// isCircuitFailure is a default function for decide the result of request is failed or not
+func isCircuitFailure(response *http.Response, err error) bool {
+
+	// the context is ended by the caller, so it isn't the failure of server
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	return response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests
+}
+
+// State is a function for get the current state of circuit
+func (cb *CircuitBreaker) State() CircuitState {
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state, _ := cb.currentState(time.Now())
+	return state
+}
+
+// Interceptor is a function for create the interceptor of RESTful, the request will fail fast with ErrCircuitOpen
+// when the circuit is open
+func (cb *CircuitBreaker) Interceptor() Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			generation, err := cb.beforeRequest()
+			if err != nil {
+				return nil, err
+			}
+
+			response, err := next(request)
+
+			// the request is cancelled or timed out by the caller, so it isn't the failure of server
+			if err != nil && request.Context().Err() != nil {
+				cb.releaseRequest(generation)
+				return response, err
+			}
+
+			cb.afterRequest(generation, !cb.config.IsFailure(response, err))
+
+			return response, err
+		}
+	}
+}
+
+func (cb *CircuitBreaker) beforeRequest() (uint64, error) {
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state, generation := cb.currentState(time.Now())
+
+	if state == CircuitOpen {
+		return generation, fmt.Errorf("%w: %s", ErrCircuitOpen, cb.config.Name)
+	}
+
+	if state == CircuitHalfOpen && cb.requests >= cb.config.HalfOpenMaxRequests {
+		return generation, fmt.Errorf("%w: %s", ErrCircuitOpen, cb.config.Name)
+	}
+
+	cb.requests++
+	return generation, nil
+}
+
+func (cb *CircuitBreaker) afterRequest(before uint64, success bool) {
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	state, generation := cb.currentState(now)
+
+	// the result is from the previous generation, so we ignore it
+	if generation != before {
+		return
+	}
+
+	if !success {
+		cb.failures++
+	}
+
+	switch state {
+	case CircuitClosed:
+		if cb.requests >= cb.config.MinRequests && float64(cb.failures)/float64(cb.requests) >= cb.config.FailureRatio {
+			cb.setState(CircuitOpen, now)
+		}
+	case CircuitHalfOpen:
+		if !success {
+			cb.setState(CircuitOpen, now)
+		} else if cb.requests-cb.failures >= cb.config.HalfOpenMaxRequests {
+			cb.setState(CircuitClosed, now)
+		}
+	}
+}
+
+// releaseRequest is a function for remove the request which the result is ignored from the counts
+func (cb *CircuitBreaker) releaseRequest(before uint64) {
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if _, generation := cb.currentState(time.Now()); generation == before && cb.requests > 0 {
+		cb.requests--
+	}
+}
+
+func (cb *CircuitBreaker) currentState(now time.Time) (CircuitState, uint64) {
+
+	switch cb.state {
+	case CircuitClosed:
+		if !cb.expiry.IsZero() && cb.expiry.Before(now) {
+			cb.newGeneration(now)
+		}
+	case CircuitOpen:
+		if cb.expiry.Before(now) {
+			cb.setState(CircuitHalfOpen, now)
+		}
+	}
+
+	return cb.state, cb.generation
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState, now time.Time) {
+
+	if cb.state == state {
+		return
+	}
+
+	from := cb.state
+	cb.state = state
+	cb.newGeneration(now)
+
+	if cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(cb.config.Name, from, state)
+	}
+}
+
+func (cb *CircuitBreaker) newGeneration(now time.Time) {
+
+	cb.generation++
+	cb.requests = 0
+	cb.failures = 0
+
+	switch cb.state {
+	case CircuitClosed:
+		cb.expiry = time.Time{}
+		if cb.config.Interval > 0 {
+			cb.expiry = now.Add(cb.config.Interval)
+		}
+	case CircuitOpen:
+		cb.expiry = now.Add(cb.config.CoolDown)
+	default:
+		cb.expiry = time.Time{}
+	}
+}
@@ -0,0 +1,350 @@
+package goutil
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response stored by the HTTPCache
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// RequestHeader is the headers of request which are listed by the Vary header
+	RequestHeader http.Header
+	StoredAt      time.Time
+}
+
+// CacheStore is a storage of HTTPCache, implement it for store the response in e.g. redis
+type CacheStore interface {
+	Get(key string) (CachedResponse, bool)
+	Set(key string, response CachedResponse)
+	Delete(key string)
+}
+
+// lruCacheStore is an in-memory CacheStore which evicts the least recently used response
+type lruCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type lruCacheItem struct {
+	key      string
+	response CachedResponse
+}
+
+// NewLRUCacheStore is a function for create the in-memory CacheStore with the capacity of responses
+func NewLRUCacheStore(capacity int) CacheStore {
+
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &lruCacheStore{
+		capacity: capacity,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (s *lruCacheStore) Get(key string) (CachedResponse, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return CachedResponse{}, false
+	}
+
+	s.order.MoveToFront(element)
+	return element.Value.(*lruCacheItem).response.clone(), true
+}
+
+func (s *lruCacheStore) Set(key string, response CachedResponse) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		element.Value.(*lruCacheItem).response = response
+		s.order.MoveToFront(element)
+		return
+	}
+
+	s.items[key] = s.order.PushFront(&lruCacheItem{key: key, response: response})
+
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*lruCacheItem).key)
+	}
+}
+
+func (s *lruCacheStore) Delete(key string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.order.Remove(element)
+		delete(s.items, key)
+	}
+}
+
+// HTTPCache is a private cache of GET responses which honours Cache-Control & Expires,
+// the stale response is revalidated with If-None-Match & If-Modified-Since
+type HTTPCache struct {
+	store CacheStore
+}
+
+// NewHTTPCache is a function for create the cache, the default store is LRU with 1000 responses
+func NewHTTPCache(store CacheStore) *HTTPCache {
+
+	if store == nil {
+		store = NewLRUCacheStore(1000)
+	}
+
+	return &HTTPCache{store: store}
+}
+
+// WithCache is a function for cache the GET responses of client, register it before the other interceptors
+// so the cached response doesn't wait the limiter
+func WithCache(store CacheStore) RESTfulOption {
+	return func(r *RESTful) {
+		r.Use(NewHTTPCache(store).Interceptor())
+	}
+}
+
+// Interceptor is a function for create the interceptor of RESTful
+func (c *HTTPCache) Interceptor() Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			// the streamed response (e.g. Download or Stream) and the partial content aren't read into memory
+			requestControl := parseCacheControl(request.Header)
+			if request.Method != http.MethodGet || requestControl.has("no-store") ||
+				request.Header.Get("Range") != "" || isStreaming(request, nil) {
+				return next(request)
+			}
+
+			key := request.URL.String()
+			cached, ok := c.store.Get(key)
+			if ok && !cached.matchVary(request) {
+				ok = false
+			}
+
+			if ok && !requestControl.has("no-cache") && cached.fresh(time.Now()) {
+				return cached.response(request, "HIT"), nil
+			}
+
+			// revalidate the stale response, the validators of caller aren't replaced
+			if ok {
+				if etag := cached.Header.Get("ETag"); etag != "" && request.Header.Get("If-None-Match") == "" {
+					request.Header.Set("If-None-Match", etag)
+				}
+
+				if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" && request.Header.Get("If-Modified-Since") == "" {
+					request.Header.Set("If-Modified-Since", lastModified)
+				}
+			}
+
+			response, err := next(request)
+			if err != nil {
+				return response, err
+			}
+
+			if ok && response.StatusCode == http.StatusNotModified {
+				drainBody(response)
+
+				// the header may be shared with the store, so it's cloned before updated
+				cached.Header = cached.Header.Clone()
+
+				// update the cached headers with the headers of 304
+				for _, header := range []string{"Cache-Control", "Date", "Expires", "ETag", "Last-Modified", "Vary"} {
+					if value := response.Header.Get(header); value != "" {
+						cached.Header.Set(header, value)
+					}
+				}
+
+				cached.StoredAt = time.Now()
+				c.store.Set(key, cached)
+
+				return cached.response(request, "REVALIDATED"), nil
+			}
+
+			// the response of authorized request is only stored when it's public, so the client
+			// which is shared by the users doesn't send the response of other user
+			if !isCacheable(response) || (request.Header.Get("Authorization") != "" && !parseCacheControl(response.Header).has("public")) ||
+				response.ContentLength > maxCachedBody || isStreaming(request, response) {
+				return response, nil
+			}
+
+			// the body of unknown length is read until the limit, the larger body is returned without storing it
+			body, err := io.ReadAll(io.LimitReader(response.Body, maxCachedBody+1))
+			if err != nil {
+				response.Body.Close()
+				return nil, err
+			}
+
+			if len(body) > maxCachedBody {
+				response.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), response.Body), Closer: response.Body}
+				return response, nil
+			}
+
+			response.Body.Close()
+			response.Body = io.NopCloser(bytes.NewReader(body))
+
+			c.store.Set(key, CachedResponse{
+				StatusCode:    response.StatusCode,
+				Header:        response.Header.Clone(),
+				Body:          body,
+				RequestHeader: varyHeader(request, response.Header),
+				StoredAt:      time.Now(),
+			})
+
+			return response, nil
+		}
+	}
+}
+
+// maxCachedBody is the maximum size of body which is stored by HTTPCache
+const maxCachedBody = 1 << 20
+
+// readCloser is a body which is read from the reader and closed by the closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// isCacheable is a function for check the response is able to store, the response needs the freshness or validator
+func isCacheable(response *http.Response) bool {
+
+	control := parseCacheControl(response.Header)
+	if response.StatusCode != http.StatusOK || control.has("no-store") || response.Header.Get("Vary") == "*" {
+		return false
+	}
+
+	_, hasMaxAge := control["max-age"]
+	return hasMaxAge ||
+		response.Header.Get("Expires") != "" ||
+		response.Header.Get("ETag") != "" ||
+		response.Header.Get("Last-Modified") != ""
+}
+
+// clone is a function for copy the cached response, so the caller is able to change it without lock
+func (c CachedResponse) clone() CachedResponse {
+
+	c.Header = c.Header.Clone()
+	c.RequestHeader = c.RequestHeader.Clone()
+	c.Body = append([]byte(nil), c.Body...)
+
+	return c
+}
+
+// fresh is a function for check the cached response is able to use without revalidation
+func (c CachedResponse) fresh(now time.Time) bool {
+
+	control := parseCacheControl(c.Header)
+	if control.has("no-cache") {
+		return false
+	}
+
+	age := now.Sub(c.StoredAt)
+	if value, err := strconv.Atoi(c.Header.Get("Age")); err == nil {
+		age += time.Duration(value) * time.Second
+	}
+
+	if value, ok := control["max-age"]; ok {
+		maxAge, err := strconv.Atoi(value)
+		return err == nil && age < time.Duration(maxAge)*time.Second
+	}
+
+	if expires, err := http.ParseTime(c.Header.Get("Expires")); err == nil {
+		date, err := http.ParseTime(c.Header.Get("Date"))
+		if err != nil {
+			date = c.StoredAt
+		}
+		return age < expires.Sub(date)
+	}
+
+	return false
+}
+
+// matchVary is a function for check the request has the same headers listed by Vary
+func (c CachedResponse) matchVary(request *http.Request) bool {
+	for key, values := range c.RequestHeader {
+		if strings.Join(request.Header.Values(key), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// response is a function for create the response from cache, X-Cache header is set with the status
+func (c CachedResponse) response(request *http.Request, status string) *http.Response {
+
+	header := c.Header.Clone()
+	header.Set("X-Cache", status)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       request,
+	}
+}
+
+// varyHeader is a function for get the headers of request which are listed by Vary
+func varyHeader(request *http.Request, header http.Header) http.Header {
+
+	vary := http.Header{}
+	for _, value := range header.Values("Vary") {
+		for _, key := range strings.Split(value, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				vary[http.CanonicalHeaderKey(key)] = request.Header.Values(key)
+			}
+		}
+	}
+
+	return vary
+}
+
+type cacheControl map[string]string
+
+func (c cacheControl) has(directive string) bool {
+	_, ok := c[directive]
+	return ok
+}
+
+// parseCacheControl is a function for parse the directives of Cache-Control header
+func parseCacheControl(header http.Header) cacheControl {
+
+	control := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+
+			key, val, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+				control[key] = strings.Trim(strings.TrimSpace(val), `"`)
+			}
+		}
+	}
+
+	return control
+}
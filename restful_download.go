@@ -0,0 +1,322 @@
+package goutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrChecksumMismatch is an error when the checksum of downloaded file isn't equal with the expected checksum
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrContentChanged is an error when the content is changed on the server while it's downloaded,
+	// the download is restarted from the beginning until the attempts run out
+	ErrContentChanged = errors.New("content is changed while it's downloaded")
+)
+
+type downloadConfig struct {
+	sha256     string
+	queryParam map[string]string
+	headers    map[string]string
+	attempts   int
+}
+
+// DownloadOption is a function for configure the Download
+type DownloadOption func(c *downloadConfig)
+
+// DownloadSHA256 is a function for verify the downloaded file with the SHA-256 in hex
+func DownloadSHA256(sum string) DownloadOption {
+	return func(c *downloadConfig) {
+		c.sha256 = strings.ToLower(sum)
+	}
+}
+
+// DownloadQueryParam is a function for set the query param of download request
+func DownloadQueryParam(queryParam map[string]string) DownloadOption {
+	return func(c *downloadConfig) {
+		c.queryParam = queryParam
+	}
+}
+
+// DownloadHeaders is a function for set the headers of download request
+func DownloadHeaders(headers map[string]string) DownloadOption {
+	return func(c *downloadConfig) {
+		c.headers = headers
+	}
+}
+
+// DownloadAttempts is a function for set the maximum of requests, including the first request and the resumes,
+// the default is MaxAttempts of retry policy. Every request is sent once, the broken connection & the retryable status are resumed
+func DownloadAttempts(attempts int) DownloadOption {
+	return func(c *downloadConfig) {
+		c.attempts = attempts
+	}
+}
+
+// Download is a function for download the path into dest, the content is written into dest.part first,
+// the download is resumed with HTTP Range when the connection is broken and the file is renamed into dest after it's completed.
+// The ETag or Last-Modified is kept in dest.part.validator and sent as If-Range, so the changed content is downloaded again
+func (r *RESTful) Download(ctx context.Context, path, dest string, opts ...DownloadOption) (err error) {
+
+	config := downloadConfig{attempts: r.retryPolicy.MaxAttempts}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	urlRequest, err := setQueryParam(r.baseURL, path, config.queryParam)
+	if err != nil {
+		return err
+	}
+
+	// the temporary file is kept when the download is failed, so the next call is able to resume it
+	temp := dest + ".part"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for attempt := 1; ; attempt++ {
+
+		var completed bool
+		completed, err = r.downloadPart(ctx, urlRequest, file, temp+".validator", config.headers)
+		if completed {
+			break
+		}
+
+		delay, ok := r.resumeDelay(ctx, attempt, err)
+		if attempt >= config.attempts || !ok {
+			return err
+		}
+
+		if err = sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	if config.sha256 != "" {
+		if err = verifySHA256(file, config.sha256); err != nil {
+			file.Close()
+			os.Remove(temp)
+			os.Remove(temp + ".validator")
+			return err
+		}
+	}
+
+	if err = file.Sync(); err != nil {
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(temp, dest); err != nil {
+		return err
+	}
+
+	os.Remove(temp + ".validator")
+	return nil
+}
+
+// downloadPart is a function for download the rest of content into the file, the validator (ETag or Last-Modified)
+// of the content is stored in validatorPath, so the content is only resumed when it isn't changed
+func (r *RESTful) downloadPart(ctx context.Context, urlRequest string, file *os.File, validatorPath string, headers map[string]string) (completed bool, err error) {
+
+	stat, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	offset := stat.Size()
+
+	// the content without validator isn't able to resume safely, so we download it from the beginning
+	validator := readValidator(validatorPath)
+	if validator == "" {
+		offset = 0
+	}
+
+	// the part is requested once, because Download resumes it with the rest of content
+	policy := r.retryPolicy
+	policy.MaxAttempts = 1
+
+	response, attempts, err := r.doWithPolicy(withStreaming(ctx), policy, func(ctx context.Context) (*http.Request, error) {
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, urlRequest, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		setHeader(request, headers)
+		if offset > 0 {
+			request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			request.Header.Set("If-Range", validator)
+		}
+
+		return request, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+
+		// the file has been downloaded completely by the previous attempt
+		if size, ok := parseContentRangeSize(response.Header.Get("Content-Range")); ok && size == offset {
+			return true, nil
+		}
+
+		// the file is changed, so we download it from the beginning
+		if err = resetFile(file, 0); err != nil {
+			return false, err
+		}
+
+		return false, ErrContentChanged
+
+	case response.StatusCode >= http.StatusBadRequest:
+		return false, newHTTPError(response, attempts, nil)
+
+	case response.StatusCode == http.StatusPartialContent:
+
+		start, ok := parseContentRangeStart(response.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return false, fmt.Errorf("unexpected content range: %s", response.Header.Get("Content-Range"))
+		}
+
+	default:
+
+		// the server doesn't support range or the content is changed, so we download it from the beginning
+		offset = 0
+	}
+
+	if err = resetFile(file, offset); err != nil {
+		return false, err
+	}
+
+	if offset == 0 {
+		if err = writeValidator(validatorPath, response.Header); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err = io.Copy(file, response.Body); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resumeDelay is a function for check the failed part is able to download again, it returns the delay before the next request
+func (r *RESTful) resumeDelay(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+
+	if ctx.Err() != nil {
+		return 0, false
+	}
+
+	var httpError *HTTPError
+	switch {
+	case errors.Is(err, ErrContentChanged):
+		return 0, true
+	case errors.As(err, &httpError):
+		return r.retryPolicy.backoff(attempt, &http.Response{Header: httpError.Header}), r.retryPolicy.isRetryableStatus(httpError.StatusCode)
+	case r.retryPolicy.isRetryableError(ctx, true, err):
+		return r.retryPolicy.backoff(attempt, nil), true
+	}
+
+	return 0, false
+}
+
+// readValidator is a function for read the validator of partial content, it's empty when it doesn't exist
+func readValidator(path string) string {
+
+	validator, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(validator))
+}
+
+// writeValidator is a function for store the validator of content, the weak ETag isn't used because If-Range needs the strong one
+func writeValidator(path string, header http.Header) error {
+
+	validator := header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = header.Get("Last-Modified")
+	}
+
+	if validator == "" {
+		os.Remove(path)
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(validator), 0666)
+}
+
+// resetFile is a function for truncate the file into the offset and move the cursor to the end
+func resetFile(file *os.File, offset int64) error {
+
+	if err := file.Truncate(offset); err != nil {
+		return err
+	}
+
+	_, err := file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// parseContentRangeStart is a function for get the start of Content-Range, e.g. bytes 100-199/200
+func parseContentRangeStart(contentRange string) (int64, bool) {
+
+	value, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+
+	start, _, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// parseContentRangeSize is a function for get the size of Content-Range, e.g. bytes */200
+func parseContentRangeSize(contentRange string) (int64, bool) {
+
+	_, size, ok := strings.Cut(contentRange, "/")
+	if !ok || size == "*" {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(size, 10, 64)
+	return n, err == nil
+}
+
+// verifySHA256 is a function for compare the SHA-256 of the file with the expected checksum
+func verifySHA256(file *os.File, expected string) error {
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != expected {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, sum)
+	}
+
+	return nil
+}
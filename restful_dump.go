@@ -0,0 +1,495 @@
+package goutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultMaskHeaders is a list of headers which are masked in the dump
+var DefaultMaskHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// DefaultMaskBody is a list of fields of JSON or form body which are masked in the dump
+var DefaultMaskBody = []string{"password", "secret", "client_secret", "token", "access_token", "refresh_token", "api_key"}
+
+const maskedValue = "***"
+
+const (
+	// notReplayableBody is dumped instead of the body which can't be read without consuming it
+	notReplayableBody = "<body not replayable>"
+	// multipartBodyDump is dumped instead of the multipart body, so the files aren't opened & read again
+	multipartBodyDump = "<multipart body not dumped>"
+	// largeBodyDump is dumped instead of the body which is larger than maxDumpBody
+	largeBodyDump = "<body too large to dump>"
+)
+
+// maxDumpBody is the maximum size of request body which is dumped
+const maxDumpBody = 64 << 10
+
+// isDumpPlaceholder is a function for check the body is dumped as the placeholder
+func isDumpPlaceholder(body []byte) bool {
+	switch string(body) {
+	case notReplayableBody, multipartBodyDump, largeBodyDump:
+		return true
+	}
+
+	return false
+}
+
+// DebugConfig is a configuration of debug mode
+type DebugConfig struct {
+	// Logger is used for log every attempt as curl command
+	Logger Logs
+	// MaskHeaders is a list of headers which are masked, the default is DefaultMaskHeaders
+	MaskHeaders []string
+	// MaskQuery is a list of query params which are masked
+	MaskQuery []string
+	// MaskBody is a list of fields of JSON or form body which are masked, the default is DefaultMaskBody
+	MaskBody []string
+	// HAR records the session, call HAR.WriteFile for write the HAR file
+	HAR *HARRecorder
+}
+
+// WithDebug is a function for dump every attempt of the client as curl command and HAR
+func WithDebug(config DebugConfig) RESTfulOption {
+	return func(r *RESTful) {
+		r.Use(DumpInterceptor(config))
+	}
+}
+
+// DumpInterceptor is an interceptor for dump every attempt as curl command and HAR
+func DumpInterceptor(config DebugConfig) Interceptor {
+
+	if config.MaskHeaders == nil {
+		config.MaskHeaders = DefaultMaskHeaders
+	}
+
+	if config.MaskBody == nil {
+		config.MaskBody = DefaultMaskBody
+	}
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			body, err := peekBody(request)
+			if err != nil {
+				return nil, err
+			}
+			body = maskBody(request.Header.Get("Content-Type"), body, config.MaskBody)
+
+			if config.Logger != nil {
+				config.Logger.Info(request.Context(), "outgoing request",
+					zap.String("curl", curlCommand(request, body, config.MaskHeaders, config.MaskQuery)))
+			}
+
+			if config.HAR == nil {
+				return next(request)
+			}
+
+			start := time.Now()
+			response, err := next(request)
+			if err != nil {
+				return response, err
+			}
+
+			// the body of streaming response isn't read, it may be endless (e.g. SSE) or large (e.g. download)
+			if isStreaming(request, response) {
+				config.HAR.add(start, time.Since(start), request, body, response, nil, config.MaskHeaders, config.MaskQuery)
+				return response, nil
+			}
+
+			// read the body for HAR and put it back
+			responseBody, err := io.ReadAll(response.Body)
+			response.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+			responseBody = maskBody(response.Header.Get("Content-Type"), responseBody, config.MaskBody)
+			config.HAR.add(start, time.Since(start), request, body, response, responseBody, config.MaskHeaders, config.MaskQuery)
+			return response, nil
+		}
+	}
+}
+
+// CurlCommand is a function for create the curl command of request, the headers in maskHeaders and the fields of DefaultMaskBody are masked
+func CurlCommand(request *http.Request, maskHeaders ...string) (string, error) {
+
+	body, err := peekBody(request)
+	if err != nil {
+		return "", err
+	}
+
+	body = maskBody(request.Header.Get("Content-Type"), body, DefaultMaskBody)
+	return curlCommand(request, body, maskHeaders, nil), nil
+}
+
+func curlCommand(request *http.Request, body []byte, maskHeaders, maskQuery []string) string {
+
+	command := []string{"curl", "-X", request.Method, shellQuote(maskURL(request, maskQuery))}
+
+	for _, header := range sortedHeaders(request.Header, maskHeaders) {
+		command = append(command, "-H", shellQuote(header.name+": "+header.value))
+	}
+
+	if len(body) > 0 {
+		command = append(command, "--data-binary", shellQuote(string(body)))
+	}
+
+	return strings.Join(command, " ")
+}
+
+// peekBody is a function for read the body of request without consuming it, the body which can't be read again
+// (without GetBody) isn't touched, because the retry would send the drained body. The multipart body and
+// the body which is larger than maxDumpBody aren't dumped, so the upload isn't read into memory
+func peekBody(request *http.Request) ([]byte, error) {
+
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if request.GetBody == nil {
+		return []byte(notReplayableBody), nil
+	}
+
+	// we don't need handle this error because the body is dumped when the content type is unknown
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") {
+		return []byte(multipartBodyDump), nil
+	}
+
+	if request.ContentLength > maxDumpBody {
+		return []byte(largeBodyDump), nil
+	}
+
+	reader, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxDumpBody+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > maxDumpBody {
+		return []byte(largeBodyDump), nil
+	}
+
+	return body, nil
+}
+
+// maskBody is a function for mask the fields of JSON or form body, the other bodies are returned as it is
+func maskBody(contentType string, body []byte, fields []string) []byte {
+
+	if len(body) == 0 || len(fields) == 0 || isDumpPlaceholder(body) {
+		return body
+	}
+
+	// we don't need handle this error because the body isn't masked when the content type is unknown
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case isJSON(mediaType):
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return body
+		}
+
+		masked, err := json.Marshal(maskJSON(value, fields))
+		if err != nil {
+			return body
+		}
+
+		return masked
+
+	case mediaType == string(ContentTypeFormURLEncoded):
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+
+		for key := range form {
+			if containsHeader(fields, key) {
+				form[key] = []string{maskedValue}
+			}
+		}
+
+		return []byte(form.Encode())
+	}
+
+	return body
+}
+
+// maskJSON is a function for mask the fields of JSON value recursively
+func maskJSON(value interface{}, fields []string) interface{} {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if containsHeader(fields, key) {
+				v[key] = maskedValue
+				continue
+			}
+			v[key] = maskJSON(item, fields)
+		}
+
+	case []interface{}:
+		for i, item := range v {
+			v[i] = maskJSON(item, fields)
+		}
+	}
+
+	return value
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func maskURL(request *http.Request, maskQuery []string) string {
+
+	u := *request.URL
+	if len(maskQuery) > 0 {
+		query := u.Query()
+		for _, key := range maskQuery {
+			if query.Has(key) {
+				query.Set(key, maskedValue)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted()
+}
+
+type headerPair struct {
+	name  string
+	value string
+}
+
+func sortedHeaders(header http.Header, maskHeaders []string) (headers []headerPair) {
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if containsHeader(maskHeaders, key) {
+				value = maskedValue
+			}
+			headers = append(headers, headerPair{name: key, value: value})
+		}
+	}
+
+	return headers
+}
+
+func containsHeader(headers []string, header string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h, header) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HARRecorder is a recorder of HAR (HTTP Archive) 1.2, it's safe for used by the goroutines
+type HARRecorder struct {
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// NewHARRecorder is a function for create the HAR recorder
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{}
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+func (h *HARRecorder) add(start time.Time, duration time.Duration, request *http.Request, body []byte,
+	response *http.Response, responseBody []byte, maskHeaders, maskQuery []string) {
+
+	milliseconds := float64(duration) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      request.Method,
+			URL:         maskURL(request, maskQuery),
+			HTTPVersion: request.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(request.Header, maskHeaders),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		Response: harResponse{
+			Status:      response.StatusCode,
+			StatusText:  http.StatusText(response.StatusCode),
+			HTTPVersion: response.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(response.Header, maskHeaders),
+			Content: harContent{
+				Size:     bodySize(responseBody),
+				MimeType: response.Header.Get("Content-Type"),
+				Text:     string(responseBody),
+			},
+			RedirectURL: response.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    bodySize(responseBody),
+		},
+		Timings: harTimings{Send: 0, Wait: milliseconds, Receive: 0},
+	}
+
+	for key, values := range request.URL.Query() {
+		for _, value := range values {
+			if containsHeader(maskQuery, key) {
+				value = maskedValue
+			}
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: key, Value: value})
+		}
+	}
+
+	if len(body) > 0 {
+		entry.Request.PostData = &harPostData{MimeType: request.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	h.mu.Lock()
+	h.entries = append(h.entries, entry)
+	h.mu.Unlock()
+}
+
+// bodySize is a function for get the size of body in HAR, the unknown size (e.g. streaming response) is -1
+func bodySize(body []byte) int {
+	if body == nil {
+		return -1
+	}
+
+	return len(body)
+}
+
+func harHeaders(header http.Header, maskHeaders []string) []harNameValue {
+
+	headers := []harNameValue{}
+	for _, pair := range sortedHeaders(header, maskHeaders) {
+		headers = append(headers, harNameValue{Name: pair.name, Value: pair.value})
+	}
+
+	return headers
+}
+
+// Write is a function for write the HAR into the writer
+func (h *HARRecorder) Write(w io.Writer) error {
+
+	h.mu.Lock()
+	entries := append([]harEntry{}, h.entries...)
+	h.mu.Unlock()
+
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Creator struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"creator"`
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+
+	har.Log.Version = "1.2"
+	har.Log.Creator.Name = "go-util"
+	har.Log.Creator.Version = "1.0"
+	har.Log.Entries = entries
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(har)
+}
+
+// WriteFile is a function for write the HAR into the file
+func (h *HARRecorder) WriteFile(path string) error {
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create HAR file: %w", err)
+	}
+
+	if err = h.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,58 @@
+package goutil
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// HTTPError is an error returned by RESTful when the status code of response is greater or equal than 400,
+// use errors.As for getting the detail of error
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Attempts   int
+	// ErrorResponse is the ErrorResponse of payload which filled by the body of response
+	ErrorResponse interface{}
+	// DecodeErr is the error when the body of response can't be decoded into ErrorResponse
+	DecodeErr error
+}
+
+func (e *HTTPError) Error() string {
+
+	errorMessage := fmt.Sprintf("Status code: %d / %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Body) > 0 {
+		errorMessage = fmt.Sprintf("%s, Response: %s", errorMessage, string(e.Body))
+	}
+
+	return errorMessage
+}
+
+// newHTTPError is a function for create HTTPError from the response, the body of response will be closed
+func newHTTPError(response *http.Response, attempts int, errorResponse interface{}) *HTTPError {
+
+	defer response.Body.Close()
+
+	httpError := &HTTPError{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Attempts:   attempts,
+	}
+
+	httpError.Body, httpError.DecodeErr = io.ReadAll(response.Body)
+	if httpError.DecodeErr != nil || errorResponse == nil || len(httpError.Body) == 0 {
+		return httpError
+	}
+
+	// getting content type
+	// we don't need handle this error because isn't important
+	contentType, _, _ := mime.ParseMediaType(response.Header.Get("content-type"))
+
+	if httpError.DecodeErr = decodeBody(contentType, httpError.Body, errorResponse); httpError.DecodeErr == nil {
+		httpError.ErrorResponse = errorResponse
+	}
+
+	return httpError
+}
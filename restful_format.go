@@ -0,0 +1,122 @@
+package goutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// isJSON is a function for check the media type is JSON, including the suffix +json
+func isJSON(contentType string) bool {
+	return contentType == string(ContentTypeJSON) || strings.HasSuffix(contentType, "+json")
+}
+
+// isXML is a function for check the media type is XML, including the suffix +xml
+func isXML(contentType string) bool {
+	return contentType == string(ContentTypeXML) || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml")
+}
+
+// rawPayload is a function for get the payload which can be sent as it is
+func rawPayload(payload interface{}) (io.Reader, bool) {
+
+	switch p := payload.(type) {
+	case nil:
+		return nil, true
+	case []byte:
+		return bytes.NewReader(p), true
+	case string:
+		return strings.NewReader(p), true
+	case io.Reader:
+		return p, true
+	default:
+		return nil, false
+	}
+}
+
+// newRawRequest is a function for create the request with the raw body, the body isn't closed by the client
+// because it's owned by the caller. The body which implements io.ReaderAt & io.Seeker (e.g. *os.File) is read
+// by the independent section on every attempt, so the request is able to retry and the interceptors are able to read it,
+// the other readers are sent once
+func newRawRequest(ctx context.Context, method, urlRequest string, body io.Reader) (*http.Request, error) {
+
+	if body == nil {
+		return http.NewRequestWithContext(ctx, method, urlRequest, nil)
+	}
+
+	readerAt, ok := body.(readSeekerAt)
+	if !ok {
+		return http.NewRequestWithContext(ctx, method, urlRequest, io.NopCloser(body))
+	}
+
+	size, err := readerAt.Seek(0, io.SeekEnd)
+	if err != nil || size == 0 {
+		return http.NewRequestWithContext(ctx, method, urlRequest, nil)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, urlRequest, io.NopCloser(io.NewSectionReader(readerAt, 0, size)))
+	if err != nil {
+		return nil, err
+	}
+
+	request.ContentLength = size
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(readerAt, 0, size)), nil
+	}
+
+	return request, nil
+}
+
+type streamingKey struct{}
+
+// withStreaming is a function for mark the request which the body of response is streamed to the caller,
+// so the interceptors don't read it
+func withStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+// isStreaming is a function for check the body of response is streamed to the caller, the response is nil
+// when it's checked before the request is sent
+func isStreaming(request *http.Request, response *http.Response) bool {
+
+	if streaming, _ := request.Context().Value(streamingKey{}).(bool); streaming {
+		return true
+	}
+
+	return response != nil && strings.HasPrefix(response.Header.Get("Content-Type"), string(ContentTypeEventStream))
+}
+
+// canDecode is a function for check the body of media type is able to decode into the target
+func canDecode(contentType string, target interface{}) bool {
+
+	switch target.(type) {
+	case *string, *[]byte:
+		return true
+	}
+
+	return isJSON(contentType) || isXML(contentType)
+}
+
+// isReplayable is a function for check the body of request is able to send again
+func isReplayable(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+// Stream is a function for send the request and return the body of response without reading it,
+// it's useful for download the large content. The caller must close the body
+func (r *RESTful) Stream(ctx context.Context, req RequestPayload) (body io.ReadCloser, meta *ResponseMeta, err error) {
+
+	ctx, cancel := withTimeout(ctx, req.Timeout)
+
+	response, meta, err := r.send(withStreaming(ctx), req.ErrorResponse, func(ctx context.Context) (*http.Request, error) {
+		return r.newRequest(ctx, req)
+	})
+	if err != nil {
+		cancel()
+		return nil, meta, err
+	}
+
+	// the context is cancelled when the body is closed
+	return &releaseBody{ReadCloser: response.Body, release: cancel}, meta, nil
+}
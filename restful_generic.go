@@ -0,0 +1,79 @@
+package goutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ResponseMeta is the metadata of response returned by Do
+type ResponseMeta struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Attempts   int
+}
+
+// Do is a function for send the request and decode the body of response into T,
+// the Response of payload is ignored
+func Do[T any](ctx context.Context, client *RESTful, req RequestPayload) (result T, meta *ResponseMeta, err error) {
+
+	ctx, cancel := withTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	response, meta, err := client.send(ctx, req.ErrorResponse, func(ctx context.Context) (*http.Request, error) {
+		return client.newRequest(ctx, req)
+	})
+	if err != nil {
+		return result, meta, err
+	}
+
+	defer response.Body.Close()
+	if meta.Body, err = io.ReadAll(response.Body); err != nil {
+		return result, meta, err
+	}
+
+	// getting content type
+	// we don't need handle this error because isn't important
+	contentType, _, _ := mime.ParseMediaType(response.Header.Get("content-type"))
+
+	// the body e.g. the error page of proxy isn't able to decode, so the zero value isn't returned silently
+	if len(meta.Body) > 0 && !canDecode(contentType, &result) {
+		return result, meta, fmt.Errorf("the content type %q can't be decoded into %T", contentType, result)
+	}
+
+	err = decodeBody(contentType, meta.Body, &result)
+	return result, meta, err
+}
+
+// statusCode is a function for get the status code of meta, it's zero when there is no response
+func (m *ResponseMeta) statusCode() int {
+
+	if m == nil {
+		return 0
+	}
+
+	return m.StatusCode
+}
+
+// Get is a function for send the GET request and decode the body of response into T
+func Get[T any](ctx context.Context, client *RESTful, path string, queryParam map[string]string) (T, *ResponseMeta, error) {
+	return Do[T](ctx, client, RequestPayload{
+		Path:        path,
+		QueryParam:  queryParam,
+		Method:      http.MethodGet,
+		ContentType: ContentTypeJSON,
+	})
+}
+
+// Post is a function for send the POST request with JSON payload and decode the body of response into T
+func Post[T any](ctx context.Context, client *RESTful, path string, payload interface{}) (T, *ResponseMeta, error) {
+	return Do[T](ctx, client, RequestPayload{
+		Path:        path,
+		Method:      http.MethodPost,
+		ContentType: ContentTypeJSON,
+		Payload:     payload,
+	})
+}
@@ -0,0 +1,135 @@
+package goutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RoundTripFunc is a function for send a single attempt of request
+type RoundTripFunc func(request *http.Request) (*http.Response, error)
+
+// RoundTrip is a function for implement http.RoundTripper
+func (f RoundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// Interceptor is a middleware which wraps every attempt of request sent by RESTful
+type Interceptor func(next RoundTripFunc) RoundTripFunc
+
+// WithInterceptors is a function for register the interceptors when create the client
+func WithInterceptors(interceptors ...Interceptor) RESTfulOption {
+	return func(r *RESTful) {
+		r.Use(interceptors...)
+	}
+}
+
+// Use is a function for register the interceptors, the first interceptor is the outermost,
+// call it before the client is used by the other goroutines
+func (r *RESTful) Use(interceptors ...Interceptor) *RESTful {
+	r.interceptors = append(r.interceptors, interceptors...)
+	return r
+}
+
+// roundTrip is a function for send the request through the interceptors
+func (r *RESTful) roundTrip(request *http.Request) (*http.Response, error) {
+
+	next := RoundTripFunc(r.client.Do)
+	for i := len(r.interceptors) - 1; i >= 0; i-- {
+		next = r.interceptors[i](next)
+	}
+
+	return next(request)
+}
+
+// HeaderInterceptor is an interceptor for set the headers on every request
+func HeaderInterceptor(headers map[string]string) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			for key, value := range headers {
+				request.Header.Set(key, value)
+			}
+			return next(request)
+		}
+	}
+}
+
+// TokenInterceptor is an interceptor for set the bearer token on the authorization header,
+// the token is taken from tokenFunc on every request
+func TokenInterceptor(tokenFunc func(ctx context.Context) (string, error)) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			token, err := tokenFunc(request.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			request.Header.Set("Authorization", "Bearer "+token)
+			return next(request)
+		}
+	}
+}
+
+// LogInterceptor is an interceptor for log every request with the Logs
+func LogInterceptor(logger Logs) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			start := time.Now()
+			response, err := next(request)
+
+			if err != nil {
+				logger.Warning(request.Context(), fmt.Errorf("outgoing request %s %s: %w", request.Method, request.URL.Redacted(), err))
+				return response, err
+			}
+
+			logger.Info(request.Context(), "outgoing request",
+				zap.String("outgoing-method", request.Method),
+				zap.String("outgoing-url", request.URL.Redacted()),
+				zap.Int("status", response.StatusCode),
+				zap.Duration("duration", time.Since(start)))
+
+			return response, err
+		}
+	}
+}
+
+// RequestMetric is the information of a single attempt, which sent to the MetricsInterceptor
+type RequestMetric struct {
+	Method     string
+	Host       string
+	Path       string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// MetricsInterceptor is an interceptor for collect the metric of every request
+func MetricsInterceptor(collect func(metric RequestMetric)) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			start := time.Now()
+			response, err := next(request)
+
+			metric := RequestMetric{
+				Method:   request.Method,
+				Host:     request.URL.Host,
+				Path:     request.URL.Path,
+				Duration: time.Since(start),
+				Err:      err,
+			}
+
+			if response != nil {
+				metric.StatusCode = response.StatusCode
+			}
+
+			collect(metric)
+			return response, err
+		}
+	}
+}
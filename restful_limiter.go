@@ -0,0 +1,180 @@
+package goutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiter, it can be shared by the RESTful clients which call the same quota
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter is a function for create the limiter which allows rate requests per second with the burst
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// WithRateLimit is a function for limit the requests per second of the client
+func WithRateLimit(rate float64, burst int) RESTfulOption {
+	return WithRateLimiter(NewRateLimiter(rate, burst))
+}
+
+// WithRateLimiter is a function for limit the client with the limiter, use it for share the limiter between clients
+func WithRateLimiter(limiter *RateLimiter) RESTfulOption {
+	return func(r *RESTful) {
+		r.Use(limiter.Interceptor())
+	}
+}
+
+// Wait is a function for wait until the token is available, it returns the error when the context is done
+// or the token won't be available before the deadline of context
+func (l *RateLimiter) Wait(ctx context.Context) error {
+
+	if l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+
+	// refill the bucket based on the elapsed time
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	// take the token, the negative tokens mean the waiting callers
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("rate limiter: waiting %s exceeds the deadline: %w", wait, context.DeadlineExceeded)
+	}
+
+	l.mu.Unlock()
+
+	if err := sleepContext(ctx, wait); err != nil {
+
+		// give back the token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Interceptor is a function for create the interceptor of RESTful which waits the token before every attempt
+func (l *RateLimiter) Interceptor() Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			if err := l.Wait(request.Context()); err != nil {
+				return nil, err
+			}
+
+			return next(request)
+		}
+	}
+}
+
+// ConcurrencyLimiter is a limiter for the maximum of requests in flight
+type ConcurrencyLimiter struct {
+	slots chan struct{}
+}
+
+// NewConcurrencyLimiter is a function for create the limiter which allows maxInFlight requests in flight
+func NewConcurrencyLimiter(maxInFlight int) *ConcurrencyLimiter {
+
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	return &ConcurrencyLimiter{slots: make(chan struct{}, maxInFlight)}
+}
+
+// WithMaxInFlight is a function for limit the requests in flight of the client
+func WithMaxInFlight(maxInFlight int) RESTfulOption {
+	return WithConcurrencyLimiter(NewConcurrencyLimiter(maxInFlight))
+}
+
+// WithConcurrencyLimiter is a function for limit the client with the limiter, use it for share the limiter between clients
+func WithConcurrencyLimiter(limiter *ConcurrencyLimiter) RESTfulOption {
+	return func(r *RESTful) {
+		r.Use(limiter.Interceptor())
+	}
+}
+
+// Wait is a function for wait until the slot is available or the context is done
+func (l *ConcurrencyLimiter) Wait(ctx context.Context) error {
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case l.slots <- struct{}{}:
+		return nil
+	}
+}
+
+// Release is a function for release the slot taken by Wait
+func (l *ConcurrencyLimiter) Release() {
+	<-l.slots
+}
+
+// Interceptor is a function for create the interceptor of RESTful, the slot is released when the body of response is closed
+func (l *ConcurrencyLimiter) Interceptor() Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			if err := l.Wait(request.Context()); err != nil {
+				return nil, err
+			}
+
+			response, err := next(request)
+			if err != nil {
+				l.Release()
+				return response, err
+			}
+
+			response.Body = &releaseBody{ReadCloser: response.Body, release: l.Release}
+			return response, err
+		}
+	}
+}
+
+// releaseBody is a body of response which calls release once it's closed
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,221 @@
+package goutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MultipartFile is a file of multipart payload, fill one of Path, Content, FileHeader or Reader as the source
+type MultipartFile struct {
+	FieldName string
+	// FileName is the name of file, the default is the base name of Path or FileHeader
+	FileName string
+	// ContentType is the content type of part, the default is application/octet-stream
+	ContentType string
+	// Path is the path of local file
+	Path string
+	// Content is the file in memory
+	Content []byte
+	// FileHeader is the file received by gin, e.g. from c.FormFile
+	FileHeader *multipart.FileHeader
+	// Reader is the stream of file, the request isn't able to retry unless it implements io.ReaderAt & io.Seeker (e.g. *os.File)
+	Reader io.Reader
+}
+
+// readSeekerAt is a reader which is able to read by the independent sections
+type readSeekerAt interface {
+	io.ReaderAt
+	io.Seeker
+}
+
+// open is a function for open the source of file
+func (f MultipartFile) open() (io.ReadCloser, error) {
+
+	switch {
+	case f.Path != "":
+		return os.Open(f.Path)
+	case f.Content != nil:
+		return io.NopCloser(bytes.NewReader(f.Content)), nil
+	case f.FileHeader != nil:
+		return f.FileHeader.Open()
+	case f.Reader != nil:
+		// every open has its own section, so the body of retry or GetBody doesn't share the offset of the pending pipe
+		if readerAt, ok := f.Reader.(readSeekerAt); ok {
+			size, err := readerAt.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(io.NewSectionReader(readerAt, 0, size)), nil
+		}
+		return io.NopCloser(f.Reader), nil
+	default:
+		return nil, fmt.Errorf("file %s doesn't have the source", f.FieldName)
+	}
+}
+
+// replayable is a function for check the source of file is able to open again
+func (f MultipartFile) replayable() bool {
+	if f.Path != "" || f.Content != nil || f.FileHeader != nil {
+		return true
+	}
+
+	_, ok := f.Reader.(readSeekerAt)
+	return ok
+}
+
+func (f MultipartFile) fileName() string {
+	switch {
+	case f.FileName != "":
+		return f.FileName
+	case f.Path != "":
+		return filepath.Base(f.Path)
+	case f.FileHeader != nil:
+		return f.FileHeader.Filename
+	default:
+		return f.FieldName
+	}
+}
+
+func (f MultipartFile) contentType() string {
+	switch {
+	case f.ContentType != "":
+		return f.ContentType
+	case f.FileHeader != nil && f.FileHeader.Header.Get("Content-Type") != "":
+		return f.FileHeader.Header.Get("Content-Type")
+	default:
+		return string(ContentTypeOctetStream)
+	}
+}
+
+// multipartFiles is a function for parse the PayloadFile, it can be url.Values of file paths, MultipartFile or []MultipartFile
+func multipartFiles(payloadFile interface{}) ([]MultipartFile, error) {
+
+	switch p := payloadFile.(type) {
+	case nil:
+		return nil, nil
+	case url.Values:
+		var files []MultipartFile
+		for key, value := range p {
+			for _, i := range value {
+				files = append(files, MultipartFile{FieldName: key, Path: i})
+			}
+		}
+		return files, nil
+	case MultipartFile:
+		return []MultipartFile{p}, nil
+	case []MultipartFile:
+		return p, nil
+	default:
+		return nil, errors.New("payload file isn't url.Values or MultipartFile")
+	}
+}
+
+// multipartBody is a body of multipart which is streamed through io.Pipe, so the files aren't buffered in memory
+type multipartBody struct {
+	boundary string
+	fields   url.Values
+	files    []MultipartFile
+}
+
+func newMultipartBody(fields url.Values, files []MultipartFile) *multipartBody {
+	return &multipartBody{
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+		fields:   fields,
+		files:    files,
+	}
+}
+
+func (b *multipartBody) contentType() string {
+	return "multipart/form-data; boundary=" + b.boundary
+}
+
+func (b *multipartBody) replayable() bool {
+	for _, file := range b.files {
+		if !file.replayable() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// open is a function for start writing the multipart into the pipe, the sources are opened before writing
+// so the error of opening file is returned immediately
+func (b *multipartBody) open() (io.ReadCloser, error) {
+
+	sources := make([]io.ReadCloser, 0, len(b.files))
+	for _, file := range b.files {
+
+		source, err := file.open()
+		if err != nil {
+			for _, s := range sources {
+				s.Close()
+			}
+			return nil, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(b.write(writer, sources))
+	}()
+
+	return reader, nil
+}
+
+func (b *multipartBody) write(w io.Writer, sources []io.ReadCloser) error {
+
+	defer func() {
+		for _, source := range sources {
+			source.Close()
+		}
+	}()
+
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+
+	for key, value := range b.fields {
+		for _, i := range value {
+			if err := writer.WriteField(key, i); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, file := range b.files {
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(file.FieldName), escapeQuotes(file.fileName())))
+		header.Set("Content-Type", file.contentType())
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(part, sources[i]); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,290 @@
+package goutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2Config is a configuration of OAuth2TokenSource
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// RefreshToken is used for the refresh-token grant, the client-credentials grant is used when it's empty
+	RefreshToken string
+	// EndpointParams is the additional parameters of the token request
+	EndpointParams url.Values
+	// AuthInParams will send the client id & secret in the body instead of basic auth
+	AuthInParams bool
+	// ExpiryDelta is the period before the expiry when the token is considered expired, the default is 10 seconds
+	ExpiryDelta time.Duration
+	// HTTPClient is the client for request the token, the default is http.Client with timeout 30 seconds
+	HTTPClient *http.Client
+}
+
+// OAuth2Token is a token from the token endpoint
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int64     `json:"expires_in"`
+	Expiry       time.Time `json:"-"`
+}
+
+// OAuth2TokenSource is a source of token which caches the token until it's expired,
+// it's safe for used by the goroutines
+type OAuth2TokenSource struct {
+	config OAuth2Config
+
+	mu         sync.Mutex
+	token      *OAuth2Token
+	refreshing chan struct{}
+	// refreshToken is the refresh token of the next request, it's only used by the goroutine which requests the token
+	refreshToken string
+}
+
+// NewOAuth2TokenSource is a function for create the token source
+func NewOAuth2TokenSource(config OAuth2Config) *OAuth2TokenSource {
+
+	if config.ExpiryDelta <= 0 {
+		config.ExpiryDelta = 10 * time.Second
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &OAuth2TokenSource{config: config, refreshToken: config.RefreshToken}
+}
+
+// WithOAuth2 is a function for authorize every request of the client with the token from the source
+func WithOAuth2(source *OAuth2TokenSource) RESTfulOption {
+	return func(r *RESTful) {
+		r.Use(source.Interceptor())
+	}
+}
+
+// valid is a function for check the token is able to use or not
+func (s *OAuth2TokenSource) valid(token *OAuth2Token) bool {
+	return token != nil && token.AccessToken != "" &&
+		(token.Expiry.IsZero() || time.Now().Add(s.config.ExpiryDelta).Before(token.Expiry))
+}
+
+// Token is a function for get the cached token, the token is requested when it's expired,
+// only one goroutine requests the token and the others wait for it
+func (s *OAuth2TokenSource) Token(ctx context.Context) (OAuth2Token, error) {
+
+	for {
+
+		s.mu.Lock()
+
+		if s.valid(s.token) {
+			token := *s.token
+			s.mu.Unlock()
+			return token, nil
+		}
+
+		// the token is being requested by the other goroutine
+		if s.refreshing != nil {
+			refreshing := s.refreshing
+			s.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return OAuth2Token{}, ctx.Err()
+			case <-refreshing:
+				continue
+			}
+		}
+
+		refreshing := make(chan struct{})
+		s.refreshing = refreshing
+		s.mu.Unlock()
+
+		token, err := s.fetchToken(ctx)
+
+		// the failed token is dropped, so the next Token requests the new one
+		s.mu.Lock()
+		s.refreshing = nil
+		s.token = nil
+		if err == nil {
+			s.token = &token
+		}
+		s.mu.Unlock()
+		close(refreshing)
+
+		return token, err
+	}
+}
+
+// Invalidate is a function for drop the cached token when it's still the accessToken,
+// so the next Token will request the new one
+func (s *OAuth2TokenSource) Invalidate(accessToken string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken == accessToken {
+		s.token.AccessToken = ""
+	}
+}
+
+// fetchToken is a function for request the token with the refresh token, the refresh token which is rejected
+// by the server isn't sent again, and the client-credentials grant is used when the client secret is configured
+func (s *OAuth2TokenSource) fetchToken(ctx context.Context) (OAuth2Token, error) {
+
+	if s.refreshToken != "" {
+
+		token, err := s.requestToken(ctx, s.refreshToken)
+		if err == nil {
+			s.refreshToken = token.RefreshToken
+			return token, nil
+		}
+
+		// the refresh token is expired or revoked (e.g. invalid_grant)
+		var httpError *HTTPError
+		if !errors.As(err, &httpError) || (httpError.StatusCode != http.StatusBadRequest && httpError.StatusCode != http.StatusUnauthorized) {
+			return token, err
+		}
+
+		s.refreshToken = ""
+		if s.config.ClientSecret == "" {
+			return token, err
+		}
+	}
+
+	token, err := s.requestToken(ctx, "")
+	if err == nil {
+		s.refreshToken = token.RefreshToken
+	}
+
+	return token, err
+}
+
+// requestToken is a function for request the token with refresh-token grant, the client-credentials grant is used
+// when the refresh token is empty
+func (s *OAuth2TokenSource) requestToken(ctx context.Context, refreshToken string) (token OAuth2Token, err error) {
+
+	params := url.Values{}
+	for key, values := range s.config.EndpointParams {
+		params[key] = values
+	}
+
+	if refreshToken != "" {
+		params.Set("grant_type", "refresh_token")
+		params.Set("refresh_token", refreshToken)
+	} else {
+		params.Set("grant_type", "client_credentials")
+	}
+
+	if len(s.config.Scopes) > 0 {
+		params.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+
+	if s.config.AuthInParams {
+		params.Set("client_id", s.config.ClientID)
+		params.Set("client_secret", s.config.ClientSecret)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return token, err
+	}
+
+	request.Header.Set("Content-Type", string(ContentTypeFormURLEncoded))
+	request.Header.Set("Accept", string(ContentTypeJSON))
+	if !s.config.AuthInParams {
+		request.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	response, err := s.config.HTTPClient.Do(request)
+	if err != nil {
+		return token, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return token, newHTTPError(response, 1, nil)
+	}
+
+	defer response.Body.Close()
+	if err = json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return token, err
+	}
+
+	if token.AccessToken == "" {
+		return token, errors.New("oauth2: server response missing access_token")
+	}
+
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	// the server may not rotate the refresh token
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+// Interceptor is a function for create the interceptor of RESTful which sets the bearer token,
+// the request is retried once with the new token when the response is 401
+func (s *OAuth2TokenSource) Interceptor() Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			ctx := request.Context()
+
+			token, err := s.Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			setBearerToken(request, token)
+			response, err := next(request)
+			if err != nil || response.StatusCode != http.StatusUnauthorized {
+				return response, err
+			}
+
+			// the body can't be sent twice
+			if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+				return response, err
+			}
+
+			s.Invalidate(token.AccessToken)
+			fresh, err := s.Token(ctx)
+			if err != nil || fresh.AccessToken == token.AccessToken {
+				return response, nil
+			}
+
+			retry := request.Clone(ctx)
+			if request.GetBody != nil {
+				if retry.Body, err = request.GetBody(); err != nil {
+					return response, nil
+				}
+			}
+
+			drainBody(response)
+			setBearerToken(retry, fresh)
+
+			return next(retry)
+		}
+	}
+}
+
+func setBearerToken(request *http.Request, token OAuth2Token) {
+
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+
+	request.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+}
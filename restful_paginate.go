@@ -0,0 +1,397 @@
+package goutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page is the information of fetched page which is used by PageStrategy for decide the next page
+type Page struct {
+	// Number is the order of page which is fetched, start from 1
+	Number  int
+	Header  http.Header
+	Body    []byte
+	Items   int
+	BaseURL string
+	// URL is the URL of request of the page, it's used for resolve the relative link
+	URL *url.URL
+}
+
+// PageStrategy is a strategy for walk the pages of list endpoint
+type PageStrategy interface {
+	// First is a function for set up the request of first page
+	First(req *RequestPayload)
+	// Next is a function for set up the request of next page, it returns false when there is no more page
+	Next(req *RequestPayload, page Page) (bool, error)
+}
+
+// PageNumberStrategy is a strategy for the endpoint which pages by the page number, e.g. ?page=2&limit=20
+type PageNumberStrategy struct {
+	// PageParam is the query param of page number, the default is page
+	PageParam string
+	// SizeParam is the query param of page size, it isn't sent when it's empty
+	SizeParam string
+	Size      int
+	// Start is the number of first page, the default is 1
+	Start int
+}
+
+func (s PageNumberStrategy) First(req *RequestPayload) {
+
+	if s.Start == 0 {
+		s.Start = 1
+	}
+
+	setQuery(req, defaultString(s.PageParam, "page"), strconv.Itoa(s.Start))
+	if s.SizeParam != "" && s.Size > 0 {
+		setQuery(req, s.SizeParam, strconv.Itoa(s.Size))
+	}
+}
+
+func (s PageNumberStrategy) Next(req *RequestPayload, page Page) (bool, error) {
+
+	if !hasNextPage(page.Items, s.Size) {
+		return false, nil
+	}
+
+	param := defaultString(s.PageParam, "page")
+	number, err := strconv.Atoi(req.QueryParam[param])
+	if err != nil {
+		return false, err
+	}
+
+	setQuery(req, param, strconv.Itoa(number+1))
+	return true, nil
+}
+
+// OffsetStrategy is a strategy for the endpoint which pages by the offset, e.g. ?offset=40&limit=20
+type OffsetStrategy struct {
+	// OffsetParam is the query param of offset, the default is offset
+	OffsetParam string
+	// LimitParam is the query param of limit, the default is limit
+	LimitParam string
+	Limit      int
+}
+
+func (s OffsetStrategy) First(req *RequestPayload) {
+
+	setQuery(req, defaultString(s.OffsetParam, "offset"), "0")
+	if s.Limit > 0 {
+		setQuery(req, defaultString(s.LimitParam, "limit"), strconv.Itoa(s.Limit))
+	}
+}
+
+func (s OffsetStrategy) Next(req *RequestPayload, page Page) (bool, error) {
+
+	if !hasNextPage(page.Items, s.Limit) {
+		return false, nil
+	}
+
+	param := defaultString(s.OffsetParam, "offset")
+	offset, err := strconv.Atoi(req.QueryParam[param])
+	if err != nil {
+		return false, err
+	}
+
+	setQuery(req, param, strconv.Itoa(offset+page.Items))
+	return true, nil
+}
+
+// CursorStrategy is a strategy for the endpoint which pages by the cursor in the body of response
+type CursorStrategy struct {
+	// CursorParam is the query param of cursor, the default is cursor
+	CursorParam string
+	// CursorPath is the dot path of next cursor in the body, e.g. meta.next_cursor
+	CursorPath string
+}
+
+func (s CursorStrategy) First(req *RequestPayload) {}
+
+func (s CursorStrategy) Next(req *RequestPayload, page Page) (bool, error) {
+
+	raw, err := lookupJSON(page.Body, s.CursorPath)
+	if err != nil {
+		return false, err
+	}
+
+	if len(raw) == 0 || string(raw) == "null" {
+		return false, nil
+	}
+
+	// the string is unquoted and the number is sent as it's written, so the big number isn't changed into the exponent
+	value := string(bytes.TrimSpace(raw))
+	if strings.HasPrefix(value, `"`) {
+		if err = json.Unmarshal(raw, &value); err != nil {
+			return false, err
+		}
+	}
+
+	if value == "" || value == "false" {
+		return false, nil
+	}
+
+	setQuery(req, defaultString(s.CursorParam, "cursor"), value)
+	return true, nil
+}
+
+// LinkHeaderStrategy is a strategy for the endpoint which pages by the Link header (RFC 8288), e.g. GitHub API
+type LinkHeaderStrategy struct{}
+
+func (s LinkHeaderStrategy) First(req *RequestPayload) {}
+
+func (s LinkHeaderStrategy) Next(req *RequestPayload, page Page) (bool, error) {
+
+	link := nextLink(page.Header)
+	if link == "" {
+		return false, nil
+	}
+
+	// the link may be relative (RFC 8288), so it's resolved against the URL of request
+	next, err := url.Parse(link)
+	if err != nil {
+		return false, err
+	}
+
+	if page.URL != nil {
+		next = page.URL.ResolveReference(next)
+	}
+
+	path, ok := strings.CutPrefix(next.String(), page.BaseURL)
+	if !ok {
+		return false, fmt.Errorf("the next link %s isn't under the base URL %s", next, page.BaseURL)
+	}
+
+	// the next link already contains the query
+	req.Path = path
+	req.QueryParam = nil
+	return true, nil
+}
+
+// PaginateConfig is a configuration of Paginate
+type PaginateConfig struct {
+	Strategy PageStrategy
+	// ItemsPath is the dot path of items in the body, e.g. data.items, the body is the items when it's empty
+	ItemsPath string
+	// MaxPages is the maximum of pages which are fetched, zero means unlimited
+	MaxPages int
+}
+
+// Iterator is an iterator of items from the list endpoint, the next page is fetched when the items of current page are consumed
+//
+//	it := goutil.Paginate[Bank](ctx, client, req, config)
+//	for it.Next() {
+//		bank := it.Item()
+//	}
+//	if err := it.Err(); err != nil {}
+type Iterator[T any] struct {
+	ctx    context.Context
+	client *RESTful
+	req    RequestPayload
+	config PaginateConfig
+
+	items   []T
+	index   int
+	page    int
+	hasNext bool
+	meta    *ResponseMeta
+	err     error
+}
+
+// Paginate is a function for create the iterator of items, the items are decoded into T
+func Paginate[T any](ctx context.Context, client *RESTful, req RequestPayload, config PaginateConfig) *Iterator[T] {
+
+	// copy the query param, so the request of caller isn't changed
+	queryParam := make(map[string]string, len(req.QueryParam))
+	for key, value := range req.QueryParam {
+		queryParam[key] = value
+	}
+	req.QueryParam = queryParam
+
+	if config.Strategy != nil {
+		config.Strategy.First(&req)
+	}
+
+	return &Iterator[T]{
+		ctx:     ctx,
+		client:  client,
+		req:     req,
+		config:  config,
+		index:   -1,
+		hasNext: true,
+	}
+}
+
+// Next is a function for move to the next item, it returns false when there is no more item or the error occurs
+func (it *Iterator[T]) Next() bool {
+
+	for {
+
+		if it.err != nil {
+			return false
+		}
+
+		if it.index+1 < len(it.items) {
+			it.index++
+			return true
+		}
+
+		if !it.hasNext || (it.config.MaxPages > 0 && it.page >= it.config.MaxPages) {
+			return false
+		}
+
+		it.fetch()
+	}
+}
+
+// Item is a function for get the current item
+func (it *Iterator[T]) Item() T {
+	return it.items[it.index]
+}
+
+// Err is a function for get the error which stops the iterator
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Meta is a function for get the metadata of the last fetched page
+func (it *Iterator[T]) Meta() *ResponseMeta {
+	return it.meta
+}
+
+// All is a function for collect the rest of items
+func (it *Iterator[T]) All() ([]T, error) {
+
+	var items []T
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+
+	return items, it.Err()
+}
+
+func (it *Iterator[T]) fetch() {
+
+	urlRequest, err := setQueryParam(it.client.baseURL, it.req.Path, it.req.QueryParam)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	pageURL, err := url.Parse(urlRequest)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	body, meta, err := Do[[]byte](it.ctx, it.client, it.req)
+	it.meta = meta
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	raw, err := lookupJSON(body, it.config.ItemsPath)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	it.items, it.index = nil, -1
+	if len(raw) > 0 && string(raw) != "null" {
+		if it.err = json.Unmarshal(raw, &it.items); it.err != nil {
+			return
+		}
+	}
+
+	it.page++
+	it.hasNext = false
+	if it.config.Strategy != nil && len(it.items) > 0 {
+		it.hasNext, it.err = it.config.Strategy.Next(&it.req, Page{
+			Number:  it.page,
+			Header:  meta.Header,
+			Body:    body,
+			Items:   len(it.items),
+			BaseURL: it.client.baseURL,
+			URL:     pageURL,
+		})
+	}
+}
+
+// lookupJSON is a function for get the value of dot path from the JSON
+func lookupJSON(body []byte, path string) (json.RawMessage, error) {
+
+	raw := json.RawMessage(body)
+	if path == "" {
+		return raw, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, fmt.Errorf("the path %s isn't found: %w", path, err)
+		}
+
+		raw = object[key]
+		if raw == nil {
+			return nil, nil
+		}
+	}
+
+	return raw, nil
+}
+
+// nextLink is a function for get the URL of rel="next" from the Link header
+func nextLink(header http.Header) string {
+
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+
+			segments := strings.Split(link, ";")
+			if len(segments) < 2 {
+				continue
+			}
+
+			for _, param := range segments[1:] {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "rel") && strings.Trim(val, `"`) == "next" {
+					return strings.Trim(strings.TrimSpace(segments[0]), "<>")
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// hasNextPage is a function for guess the next page exists, the full page means there may be the next page
+func hasNextPage(items, size int) bool {
+	if size > 0 {
+		return items >= size
+	}
+
+	return items > 0
+}
+
+func setQuery(req *RequestPayload, key, value string) {
+
+	if req.QueryParam == nil {
+		req.QueryParam = map[string]string{}
+	}
+
+	req.QueryParam[key] = value
+}
+
+func defaultString(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
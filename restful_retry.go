@@ -0,0 +1,188 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy is a configuration for decide when & how long the RESTful client retries the request
+type RetryPolicy struct {
+	// MaxAttempts is the total of attempts, including the first request
+	MaxAttempts int
+	// BaseDelay is the delay after the first failed attempt, it grows exponentially for the next attempts
+	BaseDelay time.Duration
+	// MaxDelay is the upper limit of the delay between attempts
+	MaxDelay time.Duration
+	// Jitter is a fraction (0 - 1) of the delay that will be randomized, so the clients don't retry at the same time
+	Jitter float64
+	// RetryableStatus is a list of status code that will be retried
+	RetryableStatus []int
+	// RetryOnConnectionError will retry the request when the connection is refused, reset or closed unexpectedly.
+	// The request which may have been processed by the server is only retried when the method is idempotent
+	// or the request has Idempotency-Key header
+	RetryOnConnectionError bool
+	// IgnoreRetryAfter will ignore the Retry-After header from the response, the Retry-After is limited by MaxDelay
+	IgnoreRetryAfter bool
+}
+
+// DefaultRetryableStatus is a list of status code that usually succeed when the request is retried
+var DefaultRetryableStatus = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultRetryPolicy is a function for create the retry policy which used by NewRESTful
+func DefaultRetryPolicy(maxAttempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:            maxAttempts,
+		BaseDelay:              100 * time.Millisecond,
+		MaxDelay:               5 * time.Second,
+		Jitter:                 0.5,
+		RetryableStatus:        DefaultRetryableStatus,
+		RetryOnConnectionError: true,
+	}
+}
+
+// WithRetryPolicy is a function for replace the retry policy of the client
+func WithRetryPolicy(policy RetryPolicy) RESTfulOption {
+	return func(r *RESTful) {
+		r.retryPolicy = policy
+	}
+}
+
+// isRetryableStatus is a function for check the status code is able to retry or not
+func (p RetryPolicy) isRetryableStatus(statusCode int) bool {
+	return slices.Contains(p.RetryableStatus, statusCode)
+}
+
+// isRetryableError is a function for check the transport error is able to retry or not,
+// idempotent tells the request is safe to send again after it may have been processed by the server
+func (p RetryPolicy) isRetryableError(ctx context.Context, idempotent bool, err error) bool {
+
+	// the error is caused by the caller, so don't retry it
+	if !p.RetryOnConnectionError || ctx.Err() != nil {
+		return false
+	}
+
+	// the connection isn't established, so the request isn't sent yet
+	var opErr *net.OpError
+	if errors.Is(err, syscall.ECONNREFUSED) || (errors.As(err, &opErr) && opErr.Op == "dial") {
+		return true
+	}
+
+	// the server may have processed the request, e.g. the payment, so only the safe request is sent again
+	if !idempotent {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isIdempotent is a function for check the request is safe to send more than once
+func isIdempotent(request *http.Request) bool {
+
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return request.Header.Get("Idempotency-Key") != "" || request.Header.Get("X-Idempotency-Key") != ""
+}
+
+// backoff is a function for calculate the delay before the next attempt
+func (p RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+
+	// the server tells us how long we must wait
+	if response != nil && !p.IgnoreRetryAfter {
+		if delay, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			if p.MaxDelay > 0 && delay > p.MaxDelay {
+				delay = p.MaxDelay
+			}
+			return delay
+		}
+	}
+
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			delay = p.MaxDelay
+			break
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	// randomize a part of delay
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		random := time.Duration(float64(delay) * jitter)
+		if random > 0 {
+			delay = delay - random + time.Duration(rand.Int63n(int64(random)+1))
+		}
+	}
+
+	return delay
+}
+
+// parseRetryAfter is a function for parse the Retry-After header, the value can be seconds or HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
+
+// sleepContext is a function for wait the duration or until the context is done
+func sleepContext(ctx context.Context, duration time.Duration) error {
+
+	if duration <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
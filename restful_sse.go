@@ -0,0 +1,266 @@
+package goutil
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ContentTypeEventStream contentType = "text/event-stream"
+
+// minSSERetry is the minimum delay of reconnection, so the small retry of server doesn't hammer the failing upstream
+const minSSERetry = 500 * time.Millisecond
+
+// SSEEvent is an event of Server-Sent Events
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+	// Retry is the reconnection time sent by the server, zero when it isn't sent
+	Retry time.Duration
+}
+
+// SSEStream is a stream of events which reconnects with Last-Event-ID when the connection is broken
+type SSEStream struct {
+	events chan SSEEvent
+
+	mu          sync.Mutex
+	err         error
+	lastEventID string
+	retry       time.Duration
+}
+
+// Events is a function for get the channel of events, the channel is closed when the context is done or the stream is stopped
+func (s *SSEStream) Events() <-chan SSEEvent {
+	return s.events
+}
+
+// Err is a function for get the error which stops the stream, read it after the channel of events is closed
+func (s *SSEStream) Err() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// LastEventID is a function for get the id of last event
+func (s *SSEStream) LastEventID() string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastEventID
+}
+
+// Subscribe is a function for subscribe the Server-Sent Events, the first connection is made before it returns.
+// The stream is bound to the context, and the timeout of client must be disabled for the long-lived stream
+func (r *RESTful) Subscribe(ctx context.Context, req RequestPayload) (*SSEStream, error) {
+
+	stream := &SSEStream{
+		events:      make(chan SSEEvent),
+		lastEventID: req.Headers["Last-Event-ID"],
+		retry:       3 * time.Second,
+	}
+
+	body, err := r.connectSSE(ctx, req, stream.LastEventID())
+	if err != nil {
+		return nil, err
+	}
+
+	go stream.run(ctx, r, req, body)
+
+	return stream, nil
+}
+
+// connectSSE is a function for open the connection of event stream
+func (r *RESTful) connectSSE(ctx context.Context, req RequestPayload, lastEventID string) (io.ReadCloser, error) {
+
+	response, _, err := r.send(withStreaming(ctx), req.ErrorResponse, func(ctx context.Context) (*http.Request, error) {
+
+		request, err := r.newSSERequest(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Set("Accept", string(ContentTypeEventStream))
+		request.Header.Set("Cache-Control", "no-cache")
+		if lastEventID != "" {
+			request.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		return request, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the server tells the client to stop reconnecting
+	if response.StatusCode == http.StatusNoContent {
+		response.Body.Close()
+		return nil, io.EOF
+	}
+
+	// getting content type
+	// we don't need handle this error because isn't important
+	contentType, _, _ := mime.ParseMediaType(response.Header.Get("content-type"))
+	if contentType != string(ContentTypeEventStream) {
+		response.Body.Close()
+		return nil, fmt.Errorf("unexpected content type of event stream: %s", contentType)
+	}
+
+	return response.Body, nil
+}
+
+// newSSERequest is a function for build the request of event stream, the request without content type doesn't have the body
+func (r *RESTful) newSSERequest(ctx context.Context, req RequestPayload) (*http.Request, error) {
+
+	if req.ContentType != "" {
+		return r.newRequest(ctx, req)
+	}
+
+	urlRequest, err := setQueryParam(r.baseURL, req.Path, req.QueryParam)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, defaultString(req.Method, http.MethodGet), urlRequest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	setHeader(request, req.Headers)
+
+	return request, nil
+}
+
+// run is a function for read the events and reconnect the stream until the context is done
+func (s *SSEStream) run(ctx context.Context, r *RESTful, req RequestPayload, body io.ReadCloser) {
+
+	defer close(s.events)
+
+	for {
+
+		err := s.read(ctx, body)
+		body.Close()
+
+		if ctx.Err() != nil {
+			s.stop(ctx.Err())
+			return
+		}
+
+		s.mu.Lock()
+		retry := s.retry
+		s.mu.Unlock()
+
+		// reconnect until the connection is succeed or the server rejects it
+		for {
+
+			if err = sleepContext(ctx, retry); err != nil {
+				s.stop(err)
+				return
+			}
+
+			body, err = r.connectSSE(ctx, req, s.LastEventID())
+			if err == nil {
+				break
+			}
+
+			var httpError *HTTPError
+			if ctx.Err() != nil || errors.As(err, &httpError) || errors.Is(err, io.EOF) {
+				s.stop(err)
+				return
+			}
+		}
+	}
+}
+
+func (s *SSEStream) stop(err error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	s.err = err
+}
+
+// read is a function for parse the event stream and send the events into the channel
+func (s *SSEStream) read(ctx context.Context, body io.Reader) error {
+
+	reader := bufio.NewReader(body)
+	var event SSEEvent
+	var data strings.Builder
+	var hasData bool
+
+	for {
+
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// the empty line dispatches the event
+		if line == "" {
+
+			if hasData {
+				event.ID = s.LastEventID()
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if event.Event == "" {
+					event.Event = "message"
+				}
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case s.events <- event:
+				}
+			}
+
+			event, hasData = SSEEvent{}, false
+			data.Reset()
+			continue
+		}
+
+		// the line is a comment
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteString("\n")
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				s.mu.Lock()
+				s.lastEventID = value
+				s.mu.Unlock()
+			}
+		case "retry":
+			if milliseconds, err := strconv.Atoi(value); err == nil && milliseconds >= 0 {
+				event.Retry = time.Duration(milliseconds) * time.Millisecond
+				s.mu.Lock()
+				s.retry = max(event.Retry, minSSERetry)
+				s.mu.Unlock()
+			}
+		}
+	}
+}
@@ -1,16 +1,46 @@
 package goutil_test
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	goutil "github.com/muhammadrivaldy/go-util"
+	"github.com/muhammadrivaldy/go-util/resttest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
 )
 
 // go test -v -run=^TestRequestBasicAuth$
 func TestRequestBasicAuth(t *testing.T) {
-	restful := goutil.NewRESTful("http://localhost:8080", 2)
+	server := resttest.NewServer(t)
+	server.On(http.MethodGet, "/api/v1/security/users/customer/login").
+		Expect(func(t testing.TB, request *http.Request, body []byte) {
+			username, password, _ := request.BasicAuth()
+			assert.Equal(t, "haha", username)
+			assert.Equal(t, "haha", password)
+		}).
+		Reply(http.StatusInternalServerError, nil)
+
+	restful := goutil.NewRESTful(server.URL, 2)
 	statusCode, _ := restful.RequestBasicAuth(goutil.BasicAuthPayload{
 		Path:   "/api/v1/security/users/customer/login",
 		Method: http.MethodGet,
@@ -21,3 +51,1203 @@ func TestRequestBasicAuth(t *testing.T) {
 
 	assert.Equal(t, 500, statusCode)
 }
+
+// go test -v -run=^TestRequestWithContextTimeout$
+func TestRequestWithContextTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	restful := goutil.NewRESTful(server.URL, 3)
+	_, err := restful.RequestWithContext(context.Background(), goutil.RequestPayload{
+		Path:        "/slow",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+		Timeout:     50 * time.Millisecond,
+	})
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+// go test -v -run=^TestRequestHTTPClient$
+func TestRequestHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	// the timeout is applied regardless of the order of options, and the client of caller isn't changed
+	client := &http.Client{}
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithTimeout(50*time.Millisecond), goutil.WithHTTPClient(client))
+	_, err := restful.Request(goutil.RequestPayload{
+		Path:        "/slow",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout())
+	assert.Zero(t, client.Timeout)
+}
+
+// go test -v -run=^TestRequestRetryPolicy$
+func TestRequestRetryPolicy(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch r.URL.Path {
+		case "/unavailable":
+			if attempts < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"go-util"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	policy := goutil.DefaultRetryPolicy(3)
+	policy.BaseDelay = time.Millisecond
+	restful := goutil.NewRESTful(server.URL, 3, goutil.WithRetryPolicy(policy))
+
+	var response struct {
+		Name string `json:"name"`
+	}
+	statusCode, err := restful.Request(goutil.RequestPayload{
+		Path:        "/unavailable",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+		Response:    &response,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, "go-util", response.Name)
+
+	attempts = 0
+	statusCode, err = restful.Request(goutil.RequestPayload{
+		Path:        "/invalid",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, 1, attempts)
+}
+
+// go test -v -run=^TestRequestRetryConnectionError$
+func TestRequestRetryConnectionError(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.URL.Path == "/slow" {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		// the connection is closed after the request is received
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	policy := goutil.DefaultRetryPolicy(3)
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 10 * time.Millisecond
+	restful := goutil.NewRESTful(server.URL, 3, goutil.WithRetryPolicy(policy))
+
+	send := func(method string, headers map[string]string) {
+		attempts.Store(0)
+		_, err := restful.Request(goutil.RequestPayload{
+			Path:        "/payments",
+			Method:      method,
+			ContentType: goutil.ContentTypeJSON,
+			Payload:     map[string]int{"amount": 100},
+			Headers:     headers,
+		})
+		assert.Error(t, err)
+	}
+
+	// the payment may have been processed, so it isn't sent again
+	send(http.MethodPost, nil)
+	assert.Equal(t, int32(1), attempts.Load())
+
+	send(http.MethodPost, map[string]string{"Idempotency-Key": "payment-1"})
+	assert.Equal(t, int32(3), attempts.Load())
+
+	send(http.MethodPut, nil)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	// the Retry-After is limited by the max delay
+	start := time.Now()
+	statusCode, _ := restful.Request(goutil.RequestPayload{Path: "/slow", Method: http.MethodGet, ContentType: goutil.ContentTypeJSON})
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// go test -v -run=^TestDoContentType$
+func TestDoContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html>maintenance</html>"))
+	}))
+	defer server.Close()
+
+	restful := goutil.NewRESTful(server.URL, 1)
+
+	// the error page of proxy isn't decoded into the zero value silently
+	_, meta, err := goutil.Get[map[string]string](context.Background(), restful, "/users", nil)
+	assert.ErrorContains(t, err, `the content type "text/html" can't be decoded`)
+	assert.Equal(t, "<html>maintenance</html>", string(meta.Body))
+
+	page, _, err := goutil.Get[string](context.Background(), restful, "/users", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "<html>maintenance</html>", page)
+}
+
+// go test -v -run=^TestRequestHTTPError$
+func TestRequestHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"name is required"}`))
+	}))
+	defer server.Close()
+
+	var errorResponse struct {
+		Message string `json:"message"`
+	}
+	restful := goutil.NewRESTful(server.URL, 3)
+	_, err := restful.Request(goutil.RequestPayload{
+		Path:          "/users",
+		Method:        http.MethodPost,
+		ContentType:   goutil.ContentTypeJSON,
+		ErrorResponse: &errorResponse,
+	})
+
+	var httpError *goutil.HTTPError
+	if assert.True(t, errors.As(err, &httpError)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, httpError.StatusCode)
+		assert.Equal(t, 1, httpError.Attempts)
+		assert.Equal(t, "name is required", errorResponse.Message)
+	}
+}
+
+// go test -v -run=^TestRequestInterceptor$
+func TestRequestInterceptor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "go-util", r.Header.Get("X-Client"))
+	}))
+	defer server.Close()
+
+	var metrics []goutil.RequestMetric
+	restful := goutil.NewRESTful(server.URL, 1).Use(
+		goutil.MetricsInterceptor(func(metric goutil.RequestMetric) { metrics = append(metrics, metric) }),
+		goutil.HeaderInterceptor(map[string]string{"X-Client": "go-util"}),
+		goutil.TokenInterceptor(func(ctx context.Context) (string, error) { return "token", nil }),
+	)
+
+	statusCode, err := restful.Request(goutil.RequestPayload{
+		Path:        "/users",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	if assert.Len(t, metrics, 1) {
+		assert.Equal(t, "/users", metrics[0].Path)
+		assert.Equal(t, http.StatusOK, metrics[0].StatusCode)
+	}
+}
+
+// go test -v -run=^TestRequestPropagateContext$
+func TestRequestPropagateContext(t *testing.T) {
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var traceParents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "request-id", r.Header.Get(goutil.HeaderRequestID))
+		traceParents = append(traceParents, r.Header.Get(goutil.HeaderTraceParent))
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), goutil.KeyRequestID, "request-id")
+	ctx = context.WithValue(ctx, goutil.KeyTraceParent, traceParent)
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	for i := 0; i < 2; i++ {
+		_, err := restful.RequestWithContext(ctx, goutil.RequestPayload{
+			Path:        "/users",
+			Method:      http.MethodGet,
+			ContentType: goutil.ContentTypeJSON,
+		})
+		assert.NoError(t, err)
+	}
+
+	// the trace id & flags are kept, but every outgoing request gets the new parent id
+	if assert.Len(t, traceParents, 2) {
+		for _, value := range traceParents {
+			assert.Regexp(t, `^00-4bf92f3577b34da6a3ce929d0e0e4736-[0-9a-f]{16}-01$`, value)
+			assert.NotEqual(t, traceParent, value)
+		}
+		assert.NotEqual(t, traceParents[0], traceParents[1])
+	}
+
+	// the invalid traceparent isn't forwarded
+	traceParents = nil
+	ctx = context.WithValue(ctx, goutil.KeyTraceParent, "invalid")
+	_, err := restful.RequestWithContext(ctx, goutil.RequestPayload{
+		Path:        "/users",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{""}, traceParents)
+}
+
+// go test -v -run=^TestRequestCircuitBreaker$
+func TestRequestCircuitBreaker(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var states []goutil.CircuitState
+	breaker := goutil.NewCircuitBreaker(goutil.CircuitBreakerConfig{
+		Name:          "partner",
+		MinRequests:   2,
+		FailureRatio:  1,
+		CoolDown:      time.Minute,
+		OnStateChange: func(name string, from, to goutil.CircuitState) { states = append(states, to) },
+	})
+
+	policy := goutil.DefaultRetryPolicy(5)
+	policy.BaseDelay = time.Millisecond
+	restful := goutil.NewRESTful(server.URL, 5, goutil.WithRetryPolicy(policy), goutil.WithCircuitBreaker(breaker))
+
+	_, err := restful.Request(goutil.RequestPayload{
+		Path:        "/users",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+
+	assert.True(t, errors.Is(err, goutil.ErrCircuitOpen))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, goutil.CircuitOpen, breaker.State())
+	assert.Equal(t, []goutil.CircuitState{goutil.CircuitOpen}, states)
+}
+
+// go test -v -run=^TestRequestCircuitBreakerTimeout$
+func TestRequestCircuitBreakerTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	breaker := goutil.NewCircuitBreaker(goutil.CircuitBreakerConfig{MinRequests: 1, FailureRatio: 1})
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithCircuitBreaker(breaker))
+
+	// the short timeout of caller isn't the failure of server
+	for i := 0; i < 3; i++ {
+		_, err := restful.Request(goutil.RequestPayload{
+			Path:        "/users",
+			Method:      http.MethodGet,
+			ContentType: goutil.ContentTypeJSON,
+			Timeout:     10 * time.Millisecond,
+		})
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	}
+	assert.Equal(t, goutil.CircuitClosed, breaker.State())
+}
+
+// go test -v -run=^TestRateLimiter$
+func TestRateLimiter(t *testing.T) {
+	limiter := goutil.NewRateLimiter(10, 1)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	// the next token is available after 100ms
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(limiter.Wait(ctx), context.DeadlineExceeded))
+
+	start := time.Now()
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+// go test -v -run=^TestRequestOAuth2$
+func TestRequestOAuth2(t *testing.T) {
+	var issued int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token" {
+			issued++
+			assert.Equal(t, "client_credentials", r.FormValue("grant_type"))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, issued)
+			return
+		}
+
+		// the first token is revoked by the server
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer server.Close()
+
+	source := goutil.NewOAuth2TokenSource(goutil.OAuth2Config{
+		TokenURL:     server.URL + "/oauth/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	})
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithOAuth2(source))
+
+	for i := 0; i < 2; i++ {
+		statusCode, err := restful.Request(goutil.RequestPayload{
+			Path:        "/users",
+			Method:      http.MethodPost,
+			ContentType: goutil.ContentTypeJSON,
+			Payload:     map[string]string{"name": "go-util"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+	}
+
+	assert.Equal(t, 2, issued)
+}
+
+// go test -v -run=^TestRequestOAuth2Refresh$
+func TestRequestOAuth2Refresh(t *testing.T) {
+	var grants []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		grants = append(grants, r.FormValue("grant_type"))
+
+		// the refresh token is expired
+		if r.FormValue("grant_type") == "refresh_token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, len(grants))
+	}))
+	defer server.Close()
+
+	source := goutil.NewOAuth2TokenSource(goutil.OAuth2Config{
+		TokenURL:     server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RefreshToken: "expired",
+	})
+
+	// the rejected refresh token falls back to the client credentials, and it isn't sent again
+	token, err := source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+
+	source.Invalidate(token.AccessToken)
+	token, err = source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token-3", token.AccessToken)
+	assert.Equal(t, []string{"refresh_token", "client_credentials", "client_credentials"}, grants)
+}
+
+// go test -v -run=^TestDo$
+func TestDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Total", "1")
+		w.Write([]byte(`[{"name":"go-util"}]`))
+	}))
+	defer server.Close()
+
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	users, meta, err := goutil.Get[[]user](context.Background(), restful, "/users", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []user{{Name: "go-util"}}, users)
+	assert.Equal(t, http.StatusOK, meta.StatusCode)
+	assert.Equal(t, "1", meta.Header.Get("X-Total"))
+	assert.Equal(t, 1, meta.Attempts)
+}
+
+// go test -v -run=^TestRequestFormats$
+func TestRequestFormats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	type user struct {
+		XMLName xml.Name `xml:"user"`
+		Name    string   `xml:"name"`
+	}
+
+	restful := goutil.NewRESTful(server.URL, 1)
+
+	var response user
+	_, err := restful.Request(goutil.RequestPayload{
+		Path:        "/xml",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeXML,
+		Payload:     user{Name: "go-util"},
+		Response:    &response,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "go-util", response.Name)
+
+	var text string
+	_, err = restful.Request(goutil.RequestPayload{
+		Path:        "/text",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeTextPlain,
+		Payload:     "hello",
+		Response:    &text,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", text)
+
+	body, meta, err := restful.Stream(context.Background(), goutil.RequestPayload{
+		Path:        "/stream",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeOctetStream,
+		Payload:     strings.NewReader("large content"),
+	})
+	if assert.NoError(t, err) {
+		defer body.Close()
+		content, _ := io.ReadAll(body)
+		assert.Equal(t, "large content", string(content))
+		assert.Equal(t, http.StatusOK, meta.StatusCode)
+	}
+}
+
+// go test -v -run=^TestRequestRawFile$
+func TestRequestRawFile(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	file, err := os.CreateTemp(t.TempDir(), "payload")
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = file.WriteString("file content")
+	require.NoError(t, err)
+
+	policy := goutil.DefaultRetryPolicy(2)
+	policy.BaseDelay = time.Millisecond
+	restful := goutil.NewRESTful(server.URL, 2,
+		goutil.WithRetryPolicy(policy),
+		goutil.WithInterceptors(goutil.SignInterceptor(goutil.SignatureConfig{Secret: []byte("secret")})),
+		goutil.WithDebug(goutil.DebugConfig{Logger: &recordLogs{}}),
+	)
+
+	// the interceptors read the body independently, and the file isn't closed by the client
+	statusCode, err := restful.Request(goutil.RequestPayload{
+		Path:        "/upload",
+		Method:      http.MethodPut,
+		ContentType: goutil.ContentTypeOctetStream,
+		Payload:     file,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, []string{"file content", "file content"}, bodies)
+
+	_, err = file.Stat()
+	assert.NoError(t, err)
+}
+
+// go test -v -run=^TestRequestMultipart$
+func TestRequestMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "go-util", r.FormValue("name"))
+
+		file, header, err := r.FormFile("avatar")
+		if assert.NoError(t, err) {
+			defer file.Close()
+			content, _ := io.ReadAll(file)
+			assert.Equal(t, "avatar.png", header.Filename)
+			assert.Equal(t, "image/png", header.Header.Get("Content-Type"))
+			assert.Equal(t, "png content", string(content))
+		}
+	}))
+	defer server.Close()
+
+	// the dump reads the body by GetBody while the pipe of request is pending, so every open has its own reader
+	for _, restful := range []*goutil.RESTful{
+		goutil.NewRESTful(server.URL, 1),
+		goutil.NewRESTful(server.URL, 1, goutil.WithDebug(goutil.DebugConfig{Logger: &recordLogs{}})),
+	} {
+		statusCode, err := restful.Request(goutil.RequestPayload{
+			Path:        "/upload",
+			Method:      http.MethodPost,
+			ContentType: goutil.ContentTypeMultipartFormData,
+			Payload:     url.Values{"name": {"go-util"}},
+			PayloadFile: goutil.MultipartFile{
+				FieldName:   "avatar",
+				FileName:    "avatar.png",
+				ContentType: "image/png",
+				Reader:      strings.NewReader("png content"),
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+	}
+}
+
+// go test -v -run=^TestDownload$
+func TestDownload(t *testing.T) {
+	content := strings.Repeat("go-util ", 1024)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		// the first request is broken in the middle of content
+		w.Header().Set("ETag", `"v1"`)
+		if requests == 1 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write([]byte(content[:len(content)/2]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		assert.Equal(t, fmt.Sprintf("bytes=%d-", len(content)/2), r.Header.Get("Range"))
+		assert.Equal(t, `"v1"`, r.Header.Get("If-Range"))
+		http.ServeContent(w, r, "report.txt", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	sum := sha256.Sum256([]byte(content))
+	dest := filepath.Join(t.TempDir(), "report.txt")
+
+	policy := goutil.DefaultRetryPolicy(3)
+	policy.BaseDelay = time.Millisecond
+	restful := goutil.NewRESTful(server.URL, 3, goutil.WithRetryPolicy(policy))
+
+	err := restful.Download(context.Background(), "/report", dest, goutil.DownloadSHA256(hex.EncodeToString(sum[:])))
+	if assert.NoError(t, err) {
+		downloaded, _ := os.ReadFile(dest)
+		assert.Equal(t, content, string(downloaded))
+		assert.Equal(t, 2, requests)
+	}
+}
+
+// go test -v -run=^TestDownloadChanged$
+func TestDownloadChanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "report.txt", time.Time{}, strings.NewReader("new content"))
+	}))
+	defer server.Close()
+
+	// the partial content of previous call is from the old version
+	dest := filepath.Join(t.TempDir(), "report.txt")
+	assert.NoError(t, os.WriteFile(dest+".part", []byte("old"), 0666))
+	assert.NoError(t, os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0666))
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	if assert.NoError(t, restful.Download(context.Background(), "/report", dest)) {
+		downloaded, _ := os.ReadFile(dest)
+		assert.Equal(t, "new content", string(downloaded))
+		assert.NoFileExists(t, dest+".part.validator")
+	}
+}
+
+// go test -v -run=^TestDownloadAttempts$
+func TestDownloadAttempts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/changed" {
+			w.Header().Set("Content-Range", "bytes */100")
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+
+		// the connection is reset every time
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	policy := goutil.DefaultRetryPolicy(3)
+	policy.BaseDelay = time.Millisecond
+	restful := goutil.NewRESTful(server.URL, 3, goutil.WithRetryPolicy(policy))
+
+	// the retries of every part aren't multiplied by the resumes
+	dest := filepath.Join(t.TempDir(), "report.txt")
+	assert.Error(t, restful.Download(context.Background(), "/reset", dest))
+	assert.Equal(t, int32(3), requests.Load())
+
+	// the content which keeps changing is reported by the dedicated error
+	requests.Store(0)
+	assert.NoError(t, os.WriteFile(dest+".part", []byte("old"), 0666))
+	assert.NoError(t, os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0666))
+	err := restful.Download(context.Background(), "/changed", dest, goutil.DownloadAttempts(1))
+	assert.ErrorIs(t, err, goutil.ErrContentChanged)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// go test -v -run=^TestRequestTLS$
+func TestRequestTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	rootCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	tlsConfig, err := goutil.NewTLSConfig(goutil.TLSConfig{RootCAPEM: [][]byte{rootCA}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithTLS(tlsConfig))
+	statusCode, err := restful.Request(goutil.RequestPayload{
+		Path:        "/secure",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	// the TLS config is applied regardless of the order of options, and the client of caller isn't changed
+	client := &http.Client{}
+	restful = goutil.NewRESTful(server.URL, 1, goutil.WithTLS(tlsConfig), goutil.WithHTTPClient(client))
+	statusCode, err = restful.Request(goutil.RequestPayload{
+		Path:        "/secure",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Nil(t, client.Transport)
+
+	// the shared transport is the base of TLS config regardless of the order of options, and it isn't changed
+	shared := &http.Transport{}
+	restful = goutil.NewRESTful(server.URL, 1, goutil.WithTLS(tlsConfig), goutil.WithTransport(shared))
+	statusCode, err = restful.Request(goutil.RequestPayload{
+		Path:        "/secure",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	_, err = goutil.NewRESTful(server.URL, 1, goutil.WithTransport(shared)).Request(goutil.RequestPayload{
+		Path:        "/secure",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.ErrorContains(t, err, "certificate")
+
+	// the custom transport isn't replaced silently
+	custom := goutil.RoundTripFunc(http.DefaultTransport.RoundTrip)
+	restful = goutil.NewRESTful(server.URL, 1, goutil.WithTransport(custom), goutil.WithTLS(tlsConfig))
+	_, err = restful.Request(goutil.RequestPayload{
+		Path:        "/secure",
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	})
+	assert.ErrorContains(t, err, "the TLS or proxy can't be set")
+}
+
+// go test -v -run=^TestRequestCache$
+func TestRequestCache(t *testing.T) {
+	server := resttest.NewServer(t)
+	banks := server.On(http.MethodGet, "/banks").
+		ReplyHeader("Cache-Control", "max-age=60").
+		Reply(http.StatusOK, []string{"BCA", "BRI"})
+	regions := server.On(http.MethodGet, "/regions").
+		ReplyHeader("Cache-Control", "no-cache").
+		ReplyHeader("ETag", `"v1"`).
+		Reply(http.StatusOK, []string{"Jakarta"})
+
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithCache(nil))
+
+	// the fresh response is served from the cache
+	for i := 0; i < 2; i++ {
+		result, _, err := goutil.Get[[]string](context.Background(), restful, "/banks", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BCA", "BRI"}, result)
+	}
+	assert.Equal(t, 1, server.Calls(banks))
+
+	// the response is revalidated with the ETag
+	_, _, err := goutil.Get[[]string](context.Background(), restful, "/regions", nil)
+	assert.NoError(t, err)
+
+	regions.ExpectHeader("If-None-Match", `"v1"`).Reply(http.StatusNotModified, nil)
+	result, meta, err := goutil.Get[[]string](context.Background(), restful, "/regions", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Jakarta"}, result)
+	assert.Equal(t, "REVALIDATED", meta.Header.Get("X-Cache"))
+	assert.Equal(t, 2, server.Calls(regions))
+}
+
+// go test -v -run=^TestRequestCacheLarge$
+func TestRequestCacheLarge(t *testing.T) {
+	content := strings.Repeat("a", 2<<20)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, content)
+	}))
+	defer server.Close()
+
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithCache(nil))
+
+	// the streamed response isn't stored
+	for i := 0; i < 2; i++ {
+		body, _, err := restful.Stream(context.Background(), goutil.RequestPayload{Path: "/stream", Method: http.MethodGet, ContentType: goutil.ContentTypeJSON})
+		if assert.NoError(t, err) {
+			io.Copy(io.Discard, body)
+			body.Close()
+		}
+	}
+	assert.Equal(t, int32(2), calls.Load())
+
+	// the body which is larger than the limit isn't stored, and it's returned completely
+	for _, path := range []string{"/large", "/chunked"} {
+		calls.Store(0)
+		for i := 0; i < 2; i++ {
+			result, _, err := goutil.Get[[]byte](context.Background(), restful, path, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, len(content), len(result))
+		}
+		assert.Equal(t, int32(2), calls.Load())
+	}
+}
+
+// go test -v -race -run=^TestRequestCacheConcurrent$
+func TestRequestCacheConcurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`["Jakarta"]`))
+	}))
+	defer server.Close()
+
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithCache(nil))
+	_, _, err := goutil.Get[[]string](context.Background(), restful, "/regions", nil)
+	assert.NoError(t, err)
+
+	// every request revalidates the same cached response
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, meta, err := goutil.Get[[]string](context.Background(), restful, "/regions", nil)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Jakarta"}, result)
+			assert.Equal(t, "REVALIDATED", meta.Header.Get("X-Cache"))
+		}()
+	}
+	wg.Wait()
+}
+
+// go test -v -run=^TestRequestCacheAuthorization$
+func TestRequestCacheAuthorization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/banks" {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		fmt.Fprintf(w, `%q`, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithCache(nil))
+	get := func(path, token string) string {
+		result, _, err := goutil.Do[string](context.Background(), restful, goutil.RequestPayload{
+			Path:        path,
+			Method:      http.MethodGet,
+			ContentType: goutil.ContentTypeJSON,
+			Headers:     map[string]string{"Authorization": token},
+		})
+		assert.NoError(t, err)
+		return result
+	}
+
+	// the private response of user isn't sent to the other user
+	assert.Equal(t, `"alice"`, get("/profile", "alice"))
+	assert.Equal(t, `"bob"`, get("/profile", "bob"))
+
+	// the public response is shared
+	assert.Equal(t, `"alice"`, get("/banks", "alice"))
+	assert.Equal(t, `"alice"`, get("/banks", "bob"))
+}
+
+// go test -v -run=^TestPaginate$
+func TestPaginate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`{"data":{"items":[{"name":"BCA"},{"name":"BRI"}]}}`))
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/banks?page=3>; rel="next"`, r.Host))
+			w.Write([]byte(`{"data":{"items":[{"name":"BNI"}]}}`))
+		default:
+			w.Write([]byte(`{"data":{"items":[]}}`))
+		}
+	}))
+	defer server.Close()
+
+	type bank struct {
+		Name string `json:"name"`
+	}
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	req := goutil.RequestPayload{Path: "/banks", Method: http.MethodGet, ContentType: goutil.ContentTypeJSON}
+
+	banks, err := goutil.Paginate[bank](context.Background(), restful, req, goutil.PaginateConfig{
+		Strategy:  goutil.PageNumberStrategy{SizeParam: "limit", Size: 2},
+		ItemsPath: "data.items",
+	}).All()
+	assert.NoError(t, err)
+	assert.Equal(t, []bank{{"BCA"}, {"BRI"}, {"BNI"}}, banks)
+
+	it := goutil.Paginate[bank](context.Background(), restful, goutil.RequestPayload{
+		Path:        "/banks",
+		QueryParam:  map[string]string{"page": "2"},
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	}, goutil.PaginateConfig{Strategy: goutil.LinkHeaderStrategy{}, ItemsPath: "data.items"})
+
+	var names []string
+	for it.Next() {
+		names = append(names, it.Item().Name)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"BNI"}, names)
+}
+
+// go test -v -run=^TestCurlCommand$
+func TestCurlCommand(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "http://localhost/users?page=1", strings.NewReader(`{"name":"it's"}`))
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("Content-Type", "application/json")
+
+	command, err := goutil.CurlCommand(request, goutil.DefaultMaskHeaders...)
+	assert.NoError(t, err)
+	assert.Equal(t, `curl -X POST 'http://localhost/users?page=1' -H 'Authorization: ***' -H 'Content-Type: application/json' --data-binary '{"name":"it'\''s"}'`, command)
+}
+
+// go test -v -run=^TestRequestHAR$
+func TestRequestHAR(t *testing.T) {
+	server := resttest.NewServer(t)
+	server.On(http.MethodPost, "/users").Reply(http.StatusCreated, map[string]string{"name": "go-util"})
+
+	har := goutil.NewHARRecorder()
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithDebug(goutil.DebugConfig{HAR: har, MaskQuery: []string{"key"}}))
+	_, err := restful.Request(goutil.RequestPayload{
+		Path:        "/users",
+		QueryParam:  map[string]string{"key": "secret"},
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeJSON,
+		Payload:     map[string]string{"name": "go-util"},
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+	})
+	assert.NoError(t, err)
+
+	var buffer strings.Builder
+	assert.NoError(t, har.Write(&buffer))
+	assert.Contains(t, buffer.String(), `"status": 201`)
+	assert.NotContains(t, buffer.String(), "secret")
+}
+
+// go test -v -run=^TestPaginateCursor$
+func TestPaginateCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"items":["BCA"],"next":12345678}`))
+		case "12345678":
+			// the link is relative to the URL of request
+			w.Header().Set("Link", `<?cursor=last>; rel="next"`)
+			w.Write([]byte(`{"items":["BRI"],"next":"abc"}`))
+		default:
+			w.Write([]byte(`{"items":["BNI"]}`))
+		}
+	}))
+	defer server.Close()
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	req := goutil.RequestPayload{Path: "/banks", Method: http.MethodGet, ContentType: goutil.ContentTypeJSON}
+
+	banks, err := goutil.Paginate[string](context.Background(), restful, req, goutil.PaginateConfig{
+		Strategy:  goutil.CursorStrategy{CursorPath: "next"},
+		ItemsPath: "items",
+		MaxPages:  2,
+	}).All()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BCA", "BRI"}, banks)
+
+	banks, err = goutil.Paginate[string](context.Background(), restful, goutil.RequestPayload{
+		Path:        "/banks",
+		QueryParam:  map[string]string{"cursor": "12345678"},
+		Method:      http.MethodGet,
+		ContentType: goutil.ContentTypeJSON,
+	}, goutil.PaginateConfig{Strategy: goutil.LinkHeaderStrategy{}, ItemsPath: "items"}).All()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BRI", "BNI"}, banks)
+}
+
+// recordLogs is a Logs which records the fields of the last info
+type recordLogs struct {
+	fields map[string]string
+}
+
+func (l *recordLogs) Config(osFile *os.File, createOutput bool) {}
+func (l *recordLogs) Warning(ctx context.Context, err error)    {}
+func (l *recordLogs) Error(ctx context.Context, err error)      {}
+func (l *recordLogs) Undo()                                     {}
+func (l *recordLogs) Sync()                                     {}
+
+func (l *recordLogs) Info(ctx context.Context, msg string, zapFields ...zapcore.Field) {
+	l.fields = map[string]string{}
+	for _, field := range zapFields {
+		l.fields[field.Key] = field.String
+	}
+}
+
+// go test -v -run=^TestRequestDebugNotReplayable$
+func TestRequestDebugNotReplayable(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	logs := &recordLogs{}
+	restful := goutil.NewRESTful(server.URL, 3, goutil.WithDebug(goutil.DebugConfig{Logger: logs}))
+
+	// the pipe can't be read again, so it isn't retried and the dump doesn't consume it
+	reader, writer := io.Pipe()
+	go func() {
+		writer.Write([]byte("payment=100"))
+		writer.Close()
+	}()
+
+	statusCode, err := restful.Request(goutil.RequestPayload{
+		Path:        "/payments",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeOctetStream,
+		Payload:     reader,
+	})
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"payment=100"}, bodies)
+	assert.Contains(t, logs.fields["curl"], "<body not replayable>")
+}
+
+// go test -v -run=^TestRequestDebugLargeBody$
+func TestRequestDebugLargeBody(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sizes = append(sizes, len(body))
+	}))
+	defer server.Close()
+
+	logs := &recordLogs{}
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithDebug(goutil.DebugConfig{Logger: logs}))
+
+	// the large body isn't read into the dump, but it's sent completely
+	large := strings.Repeat("a", 1<<20)
+	_, err := restful.Request(goutil.RequestPayload{
+		Path:        "/large",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeOctetStream,
+		Payload:     large,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, logs.fields["curl"], "<body too large to dump>")
+
+	// the files of multipart aren't opened again for the dump
+	_, err = restful.Request(goutil.RequestPayload{
+		Path:        "/upload",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeMultipartFormData,
+		PayloadFile: goutil.MultipartFile{FieldName: "document", Reader: strings.NewReader(large)},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, logs.fields["curl"], "<multipart body not dumped>")
+
+	if assert.Len(t, sizes, 2) {
+		assert.Equal(t, len(large), sizes[0])
+		assert.Greater(t, sizes[1], len(large))
+	}
+}
+
+// go test -v -run=^TestRequestDebugMaskBody$
+func TestRequestDebugMaskBody(t *testing.T) {
+	server := resttest.NewServer(t)
+	server.On(http.MethodPost, "/oauth/token").Reply(http.StatusOK, map[string]string{"access_token": "token-value"})
+
+	logs := &recordLogs{}
+	har := goutil.NewHARRecorder()
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithDebug(goutil.DebugConfig{Logger: logs, HAR: har}))
+
+	var response map[string]string
+	_, err := restful.Request(goutil.RequestPayload{
+		Path:        "/oauth/token",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeJSON,
+		Payload:     map[string]string{"client_id": "go-util", "client_secret": "hidden-value"},
+		Response:    &response,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "token-value", response["access_token"])
+
+	var buffer strings.Builder
+	assert.NoError(t, har.Write(&buffer))
+	assert.Contains(t, logs.fields["curl"], `"client_secret":"***"`)
+	assert.NotContains(t, logs.fields["curl"], "hidden-value")
+	assert.NotContains(t, buffer.String(), "hidden-value")
+	assert.NotContains(t, buffer.String(), "token-value")
+}
+
+// go test -v -run=^TestSubscribeRetry$
+func TestSubscribeRetry(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 0\ndata: ping\n\n")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	stream, err := restful.Subscribe(ctx, goutil.RequestPayload{Path: "/events"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the retry of zero doesn't reconnect without the delay
+	for range stream.Events() {
+	}
+	assert.Equal(t, int32(1), connections.Load())
+}
+
+// go test -v -run=^TestSubscribeHAR$
+func TestSubscribeHAR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: paid\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the endless body of stream isn't read by the HAR
+	har := goutil.NewHARRecorder()
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithDebug(goutil.DebugConfig{HAR: har}))
+	stream, err := restful.Subscribe(ctx, goutil.RequestPayload{Path: "/events"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	select {
+	case event := <-stream.Events():
+		assert.Equal(t, "paid", event.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream is blocked by the HAR")
+	}
+
+	var buffer strings.Builder
+	assert.NoError(t, har.Write(&buffer))
+	assert.Contains(t, buffer.String(), `"bodySize": -1`)
+}
+
+// go test -v -run=^TestSubscribe$
+func TestSubscribe(t *testing.T) {
+	var connections int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections++
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		// the stream is broken after the first event, and resumed from the last event id
+		if connections == 1 {
+			fmt.Fprint(w, ": comment\nretry: 1\nid: 1\nevent: status\ndata: pending\ndata: paid\n\n")
+			return
+		}
+
+		assert.Equal(t, "1", r.Header.Get("Last-Event-ID"))
+		fmt.Fprint(w, "id: 2\ndata: settled\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	restful := goutil.NewRESTful(server.URL, 1)
+	stream, err := restful.Subscribe(ctx, goutil.RequestPayload{Path: "/events"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	event := <-stream.Events()
+	assert.Equal(t, goutil.SSEEvent{ID: "1", Event: "status", Data: "pending\npaid", Retry: time.Millisecond}, event)
+
+	event = <-stream.Events()
+	assert.Equal(t, goutil.SSEEvent{ID: "2", Event: "message", Data: "settled"}, event)
+
+	cancel()
+	_, ok := <-stream.Events()
+	assert.False(t, ok)
+	assert.True(t, errors.Is(stream.Err(), context.Canceled))
+}
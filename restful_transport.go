@@ -0,0 +1,230 @@
+package goutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TLSConfig is a configuration for create tls.Config, the PEM has priority over the file
+type TLSConfig struct {
+	// CertFile & KeyFile are the client certificate for mTLS
+	CertFile string
+	KeyFile  string
+	CertPEM  []byte
+	KeyPEM   []byte
+	// RootCAFiles & RootCAPEM are the private CA which trusted besides the system CA
+	RootCAFiles []string
+	RootCAPEM   [][]byte
+	// MinVersion is the minimum version of TLS, the default is TLS 1.2
+	MinVersion         uint16
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// NewTLSConfig is a function for create tls.Config from the TLSConfig
+func NewTLSConfig(config TLSConfig) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{
+		MinVersion:         config.MinVersion,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+
+	// client certificate
+	certPEM, keyPEM := config.CertPEM, config.KeyPEM
+	if certPEM == nil && config.CertFile != "" {
+
+		var err error
+		if certPEM, err = os.ReadFile(config.CertFile); err != nil {
+			return nil, err
+		}
+
+		if keyPEM, err = os.ReadFile(config.KeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if certPEM != nil {
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	// root CA
+	rootCAPEM := config.RootCAPEM
+	for _, file := range config.RootCAFiles {
+
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		rootCAPEM = append(rootCAPEM, pem)
+	}
+
+	if len(rootCAPEM) > 0 {
+
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		for _, pem := range rootCAPEM {
+			if !rootCAs.AppendCertsFromPEM(pem) {
+				return nil, errors.New("failed to append the root CA")
+			}
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	return tlsConfig, nil
+}
+
+// TransportConfig is a configuration for create http.Transport which can be shared by the RESTful clients
+type TransportConfig struct {
+	TLS *tls.Config
+	// Proxy is the URL of proxy, the default is from the environment variables (HTTP_PROXY, HTTPS_PROXY & NO_PROXY)
+	Proxy                 *url.URL
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+}
+
+// NewTransport is a function for create http.Transport, the empty config will be filled by the default value
+func NewTransport(config TransportConfig) *http.Transport {
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if config.DialTimeout > 0 {
+		dialer.Timeout = config.DialTimeout
+	}
+
+	if config.KeepAlive > 0 {
+		dialer.KeepAlive = config.KeepAlive
+	}
+
+	transport.DialContext = dialer.DialContext
+
+	if config.TLS != nil {
+		transport.TLSClientConfig = config.TLS
+	}
+
+	if config.Proxy != nil {
+		transport.Proxy = http.ProxyURL(config.Proxy)
+	}
+
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+
+	if config.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = config.MaxConnsPerHost
+	}
+
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+
+	if config.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	}
+
+	if config.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	}
+
+	return transport
+}
+
+// WithTransport is a function for use the transport, share the transport between clients for reuse the connection pool.
+// The transport is the base of WithTLS & WithProxy regardless of the order of options
+func WithTransport(transport http.RoundTripper) RESTfulOption {
+	return func(r *RESTful) {
+		r.transport = transport
+	}
+}
+
+// WithTLS is a function for set the TLS config of client, the transport is cloned
+// so the shared transport isn't changed
+func WithTLS(tlsConfig *tls.Config) RESTfulOption {
+	return func(r *RESTful) {
+		r.transportOptions = append(r.transportOptions, func(transport *http.Transport) {
+			transport.TLSClientConfig = tlsConfig
+		})
+	}
+}
+
+// WithProxy is a function for send the request through the proxy, the transport is cloned
+// so the shared transport isn't changed
+func WithProxy(proxyURL *url.URL) RESTfulOption {
+	return func(r *RESTful) {
+		r.transportOptions = append(r.transportOptions, func(transport *http.Transport) {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		})
+	}
+}
+
+// applyTransport is a function for set the transport of client, the base transport is WithTransport or the transport of client,
+// then it's cloned for WithTLS & WithProxy
+func (r *RESTful) applyTransport(client *http.Client) error {
+
+	if r.transport != nil {
+		client.Transport = r.transport
+	}
+
+	if len(r.transportOptions) == 0 {
+		return nil
+	}
+
+	transport, err := cloneTransport(client.Transport)
+	if err != nil {
+		return err
+	}
+
+	for _, opt := range r.transportOptions {
+		opt(transport)
+	}
+
+	client.Transport = transport
+	return nil
+}
+
+// cloneTransport is a function for clone the transport, the default transport is used when it's nil,
+// the custom RoundTripper isn't able to clone, so it returns the error instead of replacing it
+func cloneTransport(roundTripper http.RoundTripper) (*http.Transport, error) {
+
+	switch transport := roundTripper.(type) {
+	case nil:
+		return http.DefaultTransport.(*http.Transport).Clone(), nil
+	case *http.Transport:
+		return transport.Clone(), nil
+	default:
+		return nil, fmt.Errorf("the TLS or proxy can't be set on the transport %T, set it on the transport itself", roundTripper)
+	}
+}
@@ -0,0 +1,359 @@
+package resttest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Redacted is the value which replaces the secret in the cassette
+const Redacted = "REDACTED"
+
+type Mode int
+
+const (
+	// ModeReplay will replay the interactions from the cassette, the request which isn't recorded is failed
+	ModeReplay Mode = iota
+	// ModeRecord will send the request to the real server and record the interactions into the cassette
+	ModeRecord
+	// ModeReplayOrRecord will record the cassette when the file doesn't exist, otherwise it replays the cassette
+	ModeReplayOrRecord
+)
+
+// Cassette is the content of cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a pair of request & response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty"`
+}
+
+// Matcher is a function for match the request with the recorded request
+type Matcher func(request *http.Request, body []byte, recorded RecordedRequest) bool
+
+// DefaultMatcher is a function for match the request by method & URL
+func DefaultMatcher(request *http.Request, body []byte, recorded RecordedRequest) bool {
+	return request.Method == recorded.Method && request.URL.String() == recorded.URL
+}
+
+// RecorderOption is a function for configure the Recorder
+type RecorderOption func(r *Recorder)
+
+// WithRedactHeaders is a function for redact the headers of request & response in the cassette,
+// the default is Authorization, Cookie & Set-Cookie
+func WithRedactHeaders(headers ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redactHeaders = append(r.redactHeaders, headers...)
+	}
+}
+
+// WithRedactJSONFields is a function for redact the fields of JSON body in the cassette, the nested fields are redacted too
+func WithRedactJSONFields(fields ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redactFields = append(r.redactFields, fields...)
+	}
+}
+
+// WithMatcher is a function for replace the DefaultMatcher
+func WithMatcher(matcher Matcher) RecorderOption {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// WithTransport is a function for replace the transport which sends the request in record mode
+func WithTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// Recorder is a http.RoundTripper which records & replays the interactions,
+// use it with goutil.WithTransport and call Stop when the test is done
+type Recorder struct {
+	path          string
+	mode          Mode
+	transport     http.RoundTripper
+	matcher       Matcher
+	redactHeaders []string
+	redactFields  []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder is a function for create the recorder of the cassette file
+func NewRecorder(path string, mode Mode, opts ...RecorderOption) (*Recorder, error) {
+
+	r := &Recorder{
+		path:          path,
+		mode:          mode,
+		transport:     http.DefaultTransport,
+		matcher:       DefaultMatcher,
+		redactHeaders: []string{"Authorization", "Cookie", "Set-Cookie"},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeReplayOrRecord {
+		r.mode = ModeReplay
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			r.mode = ModeRecord
+		}
+	}
+
+	if r.mode == ModeReplay {
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(content, &r.cassette); err != nil {
+			return nil, err
+		}
+
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode is a function for get the mode of recorder
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip is a function for implement http.RoundTripper
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	body, err := readBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(request, body)
+	}
+
+	return r.record(request, body)
+}
+
+func (r *Recorder) replay(request *http.Request, body []byte) (*http.Response, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+
+		if r.used[i] || !r.matcher(request, body, interaction.Request) {
+			continue
+		}
+
+		r.used[i] = true
+
+		responseBody, err := decodeRecordedBody(interaction.Response.Body, interaction.Response.BodyBase64)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(responseBody)),
+			ContentLength: int64(len(responseBody)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("resttest: interaction %s %s isn't recorded in %s", request.Method, request.URL, r.path)
+}
+
+func (r *Recorder) record(request *http.Request, body []byte) (*http.Response, error) {
+
+	response, err := r.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: request.Method,
+			URL:    request.URL.String(),
+			Header: r.redactHeader(request.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     r.redactHeader(response.Header),
+		},
+	}
+
+	interaction.Request.Body, interaction.Request.BodyBase64 = encodeRecordedBody(r.redactBody(body))
+	interaction.Response.Body, interaction.Response.BodyBase64 = encodeRecordedBody(r.redactBody(responseBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return response, nil
+}
+
+// Stop is a function for write the cassette file in record mode
+func (r *Recorder) Stop() error {
+
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, content, 0644)
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+
+	header = header.Clone()
+	for _, key := range r.redactHeaders {
+		if header.Get(key) != "" {
+			header.Set(key, Redacted)
+		}
+	}
+
+	return header
+}
+
+func (r *Recorder) redactBody(body []byte) []byte {
+
+	if len(r.redactFields) == 0 || len(body) == 0 {
+		return body
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return body
+	}
+
+	redacted, err := json.Marshal(redactJSON(value, r.redactFields))
+	if err != nil {
+		return body
+	}
+
+	return redacted
+}
+
+// redactJSON is a function for replace the value of fields recursively
+func redactJSON(value interface{}, fields []string) interface{} {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if containsFold(fields, key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactJSON(item, fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item, fields)
+		}
+	}
+
+	return value
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readBody is a function for read the body of request and put it back
+func readBody(request *http.Request) ([]byte, error) {
+
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func encodeRecordedBody(body []byte) (string, bool) {
+
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func decodeRecordedBody(body string, isBase64 bool) ([]byte, error) {
+
+	if isBase64 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+
+	return []byte(body), nil
+}
@@ -0,0 +1,65 @@
+package resttest_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goutil "github.com/muhammadrivaldy/go-util"
+	"github.com/muhammadrivaldy/go-util/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v -run=^TestRecorder$
+func TestRecorder(t *testing.T) {
+	server := resttest.NewServer(t)
+	route := server.On(http.MethodPost, "/login").
+		ExpectHeader("Authorization", "Bearer secret").
+		ExpectJSON(map[string]string{"username": "go-util", "password": "secret"}).
+		Reply(http.StatusOK, map[string]string{"token": "secret-token", "name": "go-util"})
+
+	cassette := filepath.Join(t.TempDir(), "login.json")
+	payload := goutil.RequestPayload{
+		Path:        "/login",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeJSON,
+		Payload:     map[string]string{"username": "go-util", "password": "secret"},
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+	}
+
+	// record the interaction with the fake server
+	recorder, err := resttest.NewRecorder(cassette, resttest.ModeReplayOrRecord, resttest.WithRedactJSONFields("password", "token"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, resttest.ModeRecord, recorder.Mode())
+
+	var response map[string]string
+	payload.Response = &response
+	restful := goutil.NewRESTful(server.URL, 1, goutil.WithTransport(recorder))
+	_, err = restful.Request(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-token", response["token"])
+	assert.NoError(t, recorder.Stop())
+	assert.Equal(t, 1, server.Calls(route))
+
+	content, _ := os.ReadFile(cassette)
+	assert.False(t, strings.Contains(string(content), "secret"))
+
+	// replay the interaction without the server
+	recorder, err = resttest.NewRecorder(cassette, resttest.ModeReplayOrRecord)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, resttest.ModeReplay, recorder.Mode())
+
+	response = nil
+	restful = goutil.NewRESTful(server.URL, 1, goutil.WithTransport(recorder))
+	_, err = restful.Request(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, resttest.Redacted, response["token"])
+	assert.Equal(t, "go-util", response["name"])
+	assert.Equal(t, 1, server.Calls(route))
+}
@@ -0,0 +1,183 @@
+package resttest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// Server is a fake server for stub the response and assert the outgoing request
+type Server struct {
+	*httptest.Server
+	t testing.TB
+
+	mu       sync.Mutex
+	routes   []*Route
+	requests []RecordedRequest
+}
+
+// Route is a stub of method & path
+type Route struct {
+	method       string
+	path         string
+	statusCode   int
+	header       http.Header
+	body         []byte
+	expectations []func(t testing.TB, request *http.Request, body []byte)
+	calls        int
+}
+
+// NewServer is a function for start the fake server, the server is closed when the test is done
+func NewServer(t testing.TB) *Server {
+
+	s := &Server{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// On is a function for add the route of method & path, the route replies 200 by default
+func (s *Server) On(method, path string) *Route {
+
+	route := &Route{method: method, path: path, statusCode: http.StatusOK, header: http.Header{}}
+
+	s.mu.Lock()
+	s.routes = append(s.routes, route)
+	s.mu.Unlock()
+
+	return route
+}
+
+// Reply is a function for set the response, the body which isn't []byte or string is encoded into JSON
+func (r *Route) Reply(statusCode int, body interface{}) *Route {
+
+	r.statusCode = statusCode
+
+	switch b := body.(type) {
+	case nil:
+		r.body = nil
+	case []byte:
+		r.body = b
+	case string:
+		r.body = []byte(b)
+	default:
+		r.body, _ = json.Marshal(b)
+		if r.header.Get("Content-Type") == "" {
+			r.header.Set("Content-Type", "application/json")
+		}
+	}
+
+	return r
+}
+
+// ReplyHeader is a function for set the header of response
+func (r *Route) ReplyHeader(key, value string) *Route {
+	r.header.Set(key, value)
+	return r
+}
+
+// Expect is a function for assert the request which is received by the route
+func (r *Route) Expect(expect func(t testing.TB, request *http.Request, body []byte)) *Route {
+	r.expectations = append(r.expectations, expect)
+	return r
+}
+
+// ExpectHeader is a function for assert the header of request
+func (r *Route) ExpectHeader(key, value string) *Route {
+	return r.Expect(func(t testing.TB, request *http.Request, body []byte) {
+		if got := request.Header.Get(key); got != value {
+			t.Errorf("resttest: %s %s header %s = %q, want %q", r.method, r.path, key, got, value)
+		}
+	})
+}
+
+// ExpectQuery is a function for assert the query param of request
+func (r *Route) ExpectQuery(key, value string) *Route {
+	return r.Expect(func(t testing.TB, request *http.Request, body []byte) {
+		if got := request.URL.Query().Get(key); got != value {
+			t.Errorf("resttest: %s %s query %s = %q, want %q", r.method, r.path, key, got, value)
+		}
+	})
+}
+
+// ExpectJSON is a function for assert the JSON body of request
+func (r *Route) ExpectJSON(expected interface{}) *Route {
+	return r.Expect(func(t testing.TB, request *http.Request, body []byte) {
+
+		var got, want interface{}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("resttest: %s %s body isn't JSON: %s", r.method, r.path, err)
+			return
+		}
+
+		wantJSON, _ := json.Marshal(expected)
+		json.Unmarshal(wantJSON, &want)
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("resttest: %s %s body = %s, want %s", r.method, r.path, body, wantJSON)
+		}
+	})
+}
+
+// Calls is a function for get the total of requests received by the route
+func (s *Server) Calls(route *Route) int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return route.calls
+}
+
+// Requests is a function for get all of requests received by the server
+func (s *Server) Requests() []RecordedRequest {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, request *http.Request) {
+
+	body, _ := io.ReadAll(request.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, RecordedRequest{
+		Method: request.Method,
+		URL:    request.URL.String(),
+		Header: request.Header.Clone(),
+		Body:   string(body),
+	})
+
+	var route *Route
+	for _, r := range s.routes {
+		if r.method == request.Method && r.path == request.URL.Path {
+			route = r
+			route.calls++
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if route == nil {
+		s.t.Errorf("resttest: unexpected request %s %s", request.Method, request.URL)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	for _, expect := range route.expectations {
+		expect(s.t, request, body)
+	}
+
+	for key, values := range route.header {
+		w.Header()[key] = values
+	}
+
+	w.WriteHeader(route.statusCode)
+	w.Write(route.body)
+}
@@ -0,0 +1,256 @@
+package goutil
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrSignatureInvalid = errors.New("signature is not valid")
+	ErrSignatureExpired = errors.New("signature is expired")
+	ErrSignatureReplay  = errors.New("signature has been used")
+)
+
+// SignatureComponents is the part of request which is signed
+type SignatureComponents struct {
+	Method    string
+	Path      string
+	Timestamp string
+	// BodyHash is the SHA-256 of body in hex
+	BodyHash string
+}
+
+// SignatureConfig is a configuration of HMAC signature, it's used by SignInterceptor & VerifySignature
+type SignatureConfig struct {
+	Secret []byte
+	// Hash is the hash of HMAC, the default is SHA-256
+	Hash func() hash.Hash
+	// Header is the header of signature, the default is X-Signature
+	Header string
+	// TimestampHeader is the header of unix timestamp, the default is X-Timestamp
+	TimestampHeader string
+	// Canonical is a function for build the string to sign, the default is DefaultCanonical
+	Canonical func(c SignatureComponents) string
+	// ReplayWindow is the maximum age of signature, the default is 5 minutes
+	ReplayWindow time.Duration
+	// MaxBodySize is the maximum size of body which is read by VerifySignature, the default is 1 MB
+	MaxBodySize int64
+}
+
+// DefaultCanonical is a function for build the string to sign from method, path, timestamp & hash of body, separated by new line
+func DefaultCanonical(c SignatureComponents) string {
+	return strings.Join([]string{c.Method, c.Path, c.Timestamp, c.BodyHash}, "\n")
+}
+
+// withDefault is a function for fill the empty configuration, it panics when the secret is empty
+// because the signature with the empty key is able to create by anyone
+func (s SignatureConfig) withDefault() SignatureConfig {
+
+	if len(s.Secret) == 0 {
+		panic("the secret of signature must be filled")
+	}
+
+	if s.Hash == nil {
+		s.Hash = sha256.New
+	}
+
+	if s.Header == "" {
+		s.Header = "X-Signature"
+	}
+
+	if s.TimestampHeader == "" {
+		s.TimestampHeader = "X-Timestamp"
+	}
+
+	if s.Canonical == nil {
+		s.Canonical = DefaultCanonical
+	}
+
+	if s.ReplayWindow <= 0 {
+		s.ReplayWindow = 5 * time.Minute
+	}
+
+	if s.MaxBodySize <= 0 {
+		s.MaxBodySize = 1 << 20
+	}
+
+	return s
+}
+
+// sign is a function for create the signature in hex, bodyHash is the SHA-256 of body in hex
+func (s SignatureConfig) sign(method, path, timestamp, bodyHash string) string {
+
+	mac := hmac.New(s.Hash, s.Secret)
+	mac.Write([]byte(s.Canonical(SignatureComponents{
+		Method:    method,
+		Path:      path,
+		Timestamp: timestamp,
+		BodyHash:  bodyHash,
+	})))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignInterceptor is an interceptor for sign every request of RESTful with HMAC
+func SignInterceptor(config SignatureConfig) Interceptor {
+
+	config = config.withDefault()
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+
+			// the body is hashed while it's streamed, so the large body (e.g. multipart upload) isn't buffered in memory
+			bodyHash := sha256.New()
+			if request.Body != nil && request.Body != http.NoBody {
+
+				if request.GetBody == nil {
+					return nil, errors.New("the body of request can't be signed because it isn't able to read twice")
+				}
+
+				reader, err := request.GetBody()
+				if err != nil {
+					return nil, err
+				}
+
+				_, err = io.Copy(bodyHash, reader)
+				reader.Close()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			request.Header.Set(config.TimestampHeader, timestamp)
+			request.Header.Set(config.Header, config.sign(request.Method, request.URL.RequestURI(), timestamp, hex.EncodeToString(bodyHash.Sum(nil))))
+
+			return next(request)
+		}
+	}
+}
+
+// VerifySignature is a middleware for verify the HMAC signature of incoming request, e.g. webhook,
+// the request is rejected when the signature is invalid, expired or has been used
+func VerifySignature(config SignatureConfig) func(c *gin.Context) {
+
+	config = config.withDefault()
+	seen := newSignatureCache(config.ReplayWindow)
+
+	return func(c *gin.Context) {
+
+		err := verifySignature(c, config, seen)
+
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			ResponseError(c, http.StatusRequestEntityTooLarge, err, nil)
+			c.Abort()
+			return
+		}
+
+		if err != nil {
+			ResponseError(c, http.StatusUnauthorized, err, nil)
+			c.Abort()
+			return
+		}
+
+		// next handler
+		c.Next()
+	}
+}
+
+func verifySignature(c *gin.Context, config SignatureConfig, seen *signatureCache) error {
+
+	signature := c.GetHeader(config.Header)
+	timestamp := c.GetHeader(config.TimestampHeader)
+	if signature == "" || timestamp == "" {
+		return ErrSignatureInvalid
+	}
+
+	// check the timestamp is still in the window
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+
+	if age := time.Since(time.Unix(unix, 0)); age > config.ReplayWindow || age < -config.ReplayWindow {
+		return ErrSignatureExpired
+	}
+
+	// read the body and put it back for the next handler, the size is limited because the request isn't authenticated yet
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxBodySize))
+	if err != nil {
+		return err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	bodyHash := sha256.Sum256(body)
+	expected := config.sign(c.Request.Method, c.Request.URL.RequestURI(), timestamp, hex.EncodeToString(bodyHash[:]))
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+		return ErrSignatureInvalid
+	}
+
+	if !seen.add(expected) {
+		return ErrSignatureReplay
+	}
+
+	return nil
+}
+
+// signatureCache is a cache of signatures which have been used in the replay window
+type signatureCache struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+	// order is the signatures in the order of expiry, every signature has the same lifetime
+	order *list.List
+}
+
+type signatureEntry struct {
+	signature string
+	expiry    time.Time
+}
+
+func newSignatureCache(window time.Duration) *signatureCache {
+	return &signatureCache{window: window, seen: map[string]time.Time{}, order: list.New()}
+}
+
+// add is a function for store the signature, it returns false when the signature has been used
+func (s *signatureCache) add(signature string) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// evict the expired signatures from the oldest
+	now := time.Now()
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		entry := element.Value.(signatureEntry)
+		if !entry.expiry.Before(now) {
+			break
+		}
+
+		s.order.Remove(element)
+		delete(s.seen, entry.signature)
+	}
+
+	if _, ok := s.seen[signature]; ok {
+		return false
+	}
+
+	// the timestamp is allowed to skew forward, so the signature is kept for two windows
+	expiry := now.Add(2 * s.window)
+	s.seen[signature] = expiry
+	s.order.PushBack(signatureEntry{signature: signature, expiry: expiry})
+	return true
+}
@@ -0,0 +1,127 @@
+package goutil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	goutil "github.com/muhammadrivaldy/go-util"
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v -run=^TestSignature$
+func TestSignature(t *testing.T) {
+	config := goutil.SignatureConfig{Secret: []byte("secret")}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhook", goutil.VerifySignature(config), func(c *gin.Context) {
+		var payload map[string]string
+		assert.NoError(t, c.ShouldBindJSON(&payload))
+		assert.Equal(t, "paid", payload["status"])
+		goutil.ResponseOK(c, http.StatusOK, nil)
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// replay the headers of previous request
+	var signature, timestamp string
+	restful := goutil.NewRESTful(server.URL, 1).Use(
+		goutil.SignInterceptor(config),
+		func(next goutil.RoundTripFunc) goutil.RoundTripFunc {
+			return func(request *http.Request) (*http.Response, error) {
+				if signature != "" {
+					request.Header.Set("X-Signature", signature)
+					request.Header.Set("X-Timestamp", timestamp)
+				}
+				signature = request.Header.Get("X-Signature")
+				timestamp = request.Header.Get("X-Timestamp")
+				return next(request)
+			}
+		},
+	)
+
+	payload := goutil.RequestPayload{
+		Path:        "/webhook?source=test",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeJSON,
+		Payload:     map[string]string{"status": "paid"},
+	}
+
+	statusCode, err := restful.Request(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	// the same signature is rejected
+	var errorResponse goutil.Response
+	payload.ErrorResponse = &errorResponse
+	statusCode, _ = restful.Request(payload)
+	assert.Equal(t, http.StatusUnauthorized, statusCode)
+	assert.Equal(t, goutil.ErrSignatureReplay.Error(), errorResponse.Message)
+
+	// the invalid signature is rejected
+	signature = "invalid"
+	statusCode, _ = restful.Request(payload)
+	assert.Equal(t, http.StatusUnauthorized, statusCode)
+	assert.Equal(t, goutil.ErrSignatureInvalid.Error(), errorResponse.Message)
+}
+
+// go test -v -run=^TestSignatureLimit$
+func TestSignatureLimit(t *testing.T) {
+	assert.Panics(t, func() { goutil.VerifySignature(goutil.SignatureConfig{}) })
+	assert.Panics(t, func() { goutil.SignInterceptor(goutil.SignatureConfig{}) })
+
+	config := goutil.SignatureConfig{Secret: []byte("secret"), MaxBodySize: 16}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhook", goutil.VerifySignature(config), func(c *gin.Context) {
+		goutil.ResponseOK(c, http.StatusOK, nil)
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// the body is larger than the limit, so it's rejected before the signature is checked
+	restful := goutil.NewRESTful(server.URL, 1).Use(goutil.SignInterceptor(config))
+	statusCode, err := restful.Request(goutil.RequestPayload{
+		Path:        "/webhook",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeJSON,
+		Payload:     map[string]string{"status": "paid", "note": "the body is larger than the limit"},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, statusCode)
+}
+
+// go test -v -run=^TestSignatureMultipart$
+func TestSignatureMultipart(t *testing.T) {
+	config := goutil.SignatureConfig{Secret: []byte("secret")}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/upload", goutil.VerifySignature(config), func(c *gin.Context) {
+		file, err := c.FormFile("document")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(len("pdf content")), file.Size)
+		}
+		goutil.ResponseOK(c, http.StatusOK, nil)
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// the upload is signed by streaming it, and the file still reaches the server
+	restful := goutil.NewRESTful(server.URL, 1).Use(goutil.SignInterceptor(config))
+	statusCode, err := restful.Request(goutil.RequestPayload{
+		Path:        "/upload",
+		Method:      http.MethodPost,
+		ContentType: goutil.ContentTypeMultipartFormData,
+		PayloadFile: goutil.MultipartFile{FieldName: "document", Reader: strings.NewReader("pdf content")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+}
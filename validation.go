@@ -1,35 +1,139 @@
 package goutil
 
 import (
+	"context"
+	"mime/multipart"
 	"reflect"
-	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/go-playground/locales"
 	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+const (
+	LocaleEnglish   = "en"
+	LocaleIndonesia = "id"
 )
 
 type Validation struct {
 	v     *validator.Validate
 	trans *ut.Translator
+	uni   *ut.UniversalTranslator
+	// locales is the list of registered locales, the translations are registered into every locale
+	locales []string
 }
 
+// RegisterDefaultTranslations is a function for register the default messages of validator into the translator
+type RegisterDefaultTranslations func(v *validator.Validate, trans ut.Translator) error
+
 func NewValidation() (Validation, error) {
 
-	// translator
+	// translator, english is the default & fallback locale
 	en := en.New()
 	uni := ut.New(en, en)
 
-	// register translator
-	trans, _ := uni.GetTranslator("en")
 	validate := validator.New()
-	en_translations.RegisterDefaultTranslations(validate, trans)
+	vt := Validation{v: validate, uni: uni}
+
+	// register translator
+	if err := vt.RegisterLocale(en, en_translations.RegisterDefaultTranslations); err != nil {
+		return Validation{}, err
+	}
+
+	if err := vt.RegisterLocale(id.New(), id_translations.RegisterDefaultTranslations); err != nil {
+		return Validation{}, err
+	}
+
+	trans, _ := uni.GetTranslator(LocaleEnglish)
+	vt.trans = &trans
 
 	// send validate connection
-	return Validation{validate, &trans}, nil
+	return vt, nil
+
+}
+
+// RegisterLocale is a function for add the locale into the registry of Validation, register it before RegisterTranslation
+// so the custom messages are registered into the locale too
+func (vt *Validation) RegisterLocale(translator locales.Translator, register RegisterDefaultTranslations) error {
+
+	if err := vt.uni.AddTranslator(translator, true); err != nil {
+		return err
+	}
+
+	trans, _ := vt.uni.GetTranslator(translator.Locale())
+	if err := register(vt.v, trans); err != nil {
+		return err
+	}
+
+	for _, locale := range vt.locales {
+		if locale == translator.Locale() {
+			return nil
+		}
+	}
+
+	vt.locales = append(vt.locales, translator.Locale())
+	return nil
+}
+
+// translator is a function for find the translator of the languages (e.g. the value of Accept-Language header),
+// the default translator is returned when there is no registered locale
+func (vt *Validation) translator(language string) ut.Translator {
+
+	for _, locale := range parseAcceptLanguage(language) {
+		if trans, found := vt.uni.GetTranslator(locale); found {
+			return trans
+		}
+	}
+
+	return *vt.trans
+}
+
+// parseAcceptLanguage is a function for parse the Accept-Language header (e.g. id-ID,id;q=0.9,en;q=0.8)
+// into the locales which are ordered by the quality, the base language is added after the region (e.g. id_ID, id)
+func parseAcceptLanguage(language string) (result []string) {
 
+	type tag struct {
+		locale  string
+		quality float64
+	}
+
+	var tags []tag
+	for _, value := range strings.Split(language, ",") {
+
+		locale, params, _ := strings.Cut(strings.TrimSpace(value), ";")
+		if locale == "" || locale == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			quality = parsed
+		}
+
+		tags = append(tags, tag{locale: strings.ReplaceAll(locale, "-", "_"), quality: quality})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+
+	for _, t := range tags {
+		result = append(result, t.locale)
+		if base, _, ok := strings.Cut(t.locale, "_"); ok {
+			result = append(result, base)
+		}
+	}
+
+	return result
 }
 
 type ValidationErrors struct {
@@ -45,137 +149,267 @@ func (v ValidationErrors) IsErrorExists() bool {
 	return len(v.Errors) > 0
 }
 
+// ValidationStruct is a function for validate the struct, the messages are translated into the default locale (en)
 func (vt *Validation) ValidationStruct(req interface{}) (validationError ValidationErrors) {
+	return vt.validationStruct(req, *vt.trans)
+}
 
-	if err := vt.v.Struct(req); err != nil {
+// ValidationStructLocale is a function for validate the struct, the messages are translated into the locale
+// (e.g. id, en or the value of Accept-Language header), the default locale is used when the locale isn't registered
+func (vt *Validation) ValidationStructLocale(req interface{}, locale string) (validationError ValidationErrors) {
+	return vt.validationStruct(req, vt.translator(locale))
+}
 
-		for _, errs := range err.(validator.ValidationErrors) {
+// ValidationStructContext is a function for validate the struct, the messages are translated into the language
+// of caller which is stored by SetContext
+func (vt *Validation) ValidationStructContext(ctx context.Context, req interface{}) (validationError ValidationErrors) {
 
-			field, _ := reflect.TypeOf(req).FieldByName(errs.Field())
-			filedJSONName, _ := field.Tag.Lookup("json")
-			validationError.Errors = append(validationError.Errors, ValidationError{
-				Field:   filedJSONName,
-				Message: strings.Replace(errs.Translate(*vt.trans), errs.Field(), filedJSONName, -1),
-			})
+	language, _ := ctx.Value(KeyLanguage).(string)
+	return vt.ValidationStructLocale(req, language)
+}
+
+func (vt *Validation) validationStruct(req interface{}, trans ut.Translator) (validationError ValidationErrors) {
+
+	if err := vt.v.Struct(req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			validationError = translateErrors(req, errs, trans)
 		}
 	}
 
 	return
 }
 
-func (vt *Validation) ValidationVariable(req interface{}, attributeName string, tag string, msgErr string) (validationError ValidationErrors) {
+// translateErrors is a function for convert the errors of validator into ValidationErrors with the path of json
+func translateErrors(req interface{}, errs validator.ValidationErrors, trans ut.Translator) (validationError ValidationErrors) {
 
-	if err := vt.v.Var(req, tag); err != nil {
+	for _, fieldError := range errs {
+
+		fieldPath, fieldJSONName := jsonNamespace(reflect.TypeOf(req), fieldError.StructNamespace())
 		validationError.Errors = append(validationError.Errors, ValidationError{
-			Field:   attributeName,
-			Message: msgErr,
+			Field:   fieldPath,
+			Message: strings.Replace(fieldError.Translate(trans), fieldError.Field(), fieldJSONName, 1),
 		})
 	}
 
 	return
 }
 
-type RegisterTranslation func(v *validator.Validate, trans *ut.Translator) error
+// jsonNamespace is a function for convert the namespace of struct (e.g. User.Items[2].Address.PostalCode)
+// into the path of json (e.g. items[2].address.postal_code), it returns the path and the json name of the last field,
+// the field without json tag uses the name of uri or form tag
+func jsonNamespace(t reflect.Type, structNamespace string) (path string, name string) {
+
+	var segments []string
+	var inside int
+	var start int
+
+	// split the namespace by dot, except the dot inside the key of map
+	for i, char := range structNamespace {
+		switch char {
+		case '[':
+			inside++
+		case ']':
+			inside--
+		case '.':
+			if inside == 0 {
+				segments = append(segments, structNamespace[start:i])
+				start = i + 1
+			}
+		}
+	}
+	segments = append(segments, structNamespace[start:])
 
-func (vt *Validation) RegisterTranslation(translations ...RegisterTranslation) (err error) {
+	// the first segment is the name of struct
+	var fields []string
+	for _, segment := range segments[1:] {
 
-	registerJPG := func(ut ut.Translator) error { return ut.Add("jpg", "{0} must be a valid format", true) }
-	translationJPG := func(ut ut.Translator, fe validator.FieldError) string {
-		t, _ := ut.T("jpg", fe.Field())
-		return t
-	}
+		fieldName, index, _ := strings.Cut(segment, "[")
+		if index != "" {
+			index = "[" + index
+		}
 
-	err = vt.v.RegisterTranslation("jpg", *vt.trans, registerJPG, translationJPG)
-	if err != nil {
-		return err
-	}
+		name = fieldName
+		t = indirectType(t)
 
-	registerPNG := func(ut ut.Translator) error { return ut.Add("png", "{0} must be a valid format", true) }
-	translationPNG := func(ut ut.Translator, fe validator.FieldError) string {
-		t, _ := ut.T("png", fe.Field())
-		return t
-	}
+		if t != nil && t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(fieldName); ok {
 
-	err = vt.v.RegisterTranslation("png", *vt.trans, registerPNG, translationPNG)
-	if err != nil {
-		return err
-	}
+				jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
 
-	registerPDF := func(ut ut.Translator) error { return ut.Add("pdf", "{0} must be a valid format", true) }
-	translationPDF := func(ut ut.Translator, fe validator.FieldError) string {
-		t, _ := ut.T("pdf", fe.Field())
-		return t
+				// the embedded struct is flattened by json
+				if field.Anonymous && jsonName == "" {
+					t = field.Type
+					continue
+				}
+
+				if tagName := fieldTagName(field, jsonName); tagName != "" {
+					name = tagName
+				}
+
+				t = field.Type
+			} else {
+				t = nil
+			}
+		}
+
+		// the element of slice, array or map
+		for i := strings.Count(index, "["); i > 0 && t != nil; i-- {
+			if t = indirectType(t); t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+				t = t.Elem()
+			} else {
+				t = nil
+			}
+		}
+
+		name += index
+		fields = append(fields, name)
 	}
 
-	err = vt.v.RegisterTranslation("pdf", *vt.trans, registerPDF, translationPDF)
-	if err != nil {
-		return err
+	return strings.Join(fields, "."), name
+}
+
+// fieldTagName is a function for get the name of field in the request, the field without json tag is bound
+// from the uri or form (query), so the name is taken from those tags
+func fieldTagName(field reflect.StructField, jsonName string) string {
+
+	if jsonName != "" && jsonName != "-" {
+		return jsonName
 	}
 
-	registerImage := func(ut ut.Translator) error { return ut.Add("image", "{0} must be a valid format", true) }
-	translationImage := func(ut ut.Translator, fe validator.FieldError) string {
-		t, _ := ut.T("image", fe.Field())
-		return t
+	for _, tag := range []string{"uri", "form"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
 	}
 
-	err = vt.v.RegisterTranslation("image", *vt.trans, registerImage, translationImage)
-	if err != nil {
-		return err
+	return ""
+}
+
+// indirectType is a function for get the type which is pointed by the pointer
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
 
-	for _, transFunc := range translations {
-		err = transFunc(vt.v, vt.trans)
-		if err != nil {
-			return err
-		}
+	return t
+}
+
+func (vt *Validation) ValidationVariable(req interface{}, attributeName string, tag string, msgErr string) (validationError ValidationErrors) {
+
+	if err := vt.v.Var(req, tag); err != nil {
+		validationError.Errors = append(validationError.Errors, ValidationError{
+			Field:   attributeName,
+			Message: msgErr,
+		})
 	}
 
-	return nil
+	return
+}
+
+type RegisterTranslation func(v *validator.Validate, trans *ut.Translator) error
 
+// customMessages is the messages of custom tags for every locale, the message of english is used
+// when the locale doesn't have the message
+var customMessages = map[string]map[string]string{
+	LocaleEnglish: {
+		"jpg":              "{0} must be a valid format",
+		"png":              "{0} must be a valid format",
+		"pdf":              "{0} must be a valid format",
+		"image":            "{0} must be a valid format",
+		"maxfilesize":      "{0} must not be larger than {1}",
+		"mimetype":         "{0} must be one of the types [{1}]",
+		"maxdimension":     "{0} must not be larger than {1} pixels",
+		"mindimension":     "{0} must be at least {1} pixels",
+		"nik":              "{0} must be a valid NIK",
+		"npwp":             "{0} must be a valid NPWP",
+		"mobile_idn":       "{0} must be a valid Indonesian mobile number",
+		"postcode_idn":     "{0} must be a valid Indonesian postal code",
+		"plate_idn":        "{0} must be a valid Indonesian license plate",
+		"bank_account_idn": "{0} must be a valid bank account number",
+		"json_syntax":      "{0} must be a valid JSON",
+		"field_type":       "{0} must be of type {1}",
+	},
+	LocaleIndonesia: {
+		"jpg":              "{0} harus berformat yang valid",
+		"png":              "{0} harus berformat yang valid",
+		"pdf":              "{0} harus berformat yang valid",
+		"image":            "{0} harus berformat yang valid",
+		"maxfilesize":      "{0} tidak boleh lebih besar dari {1}",
+		"mimetype":         "{0} harus bertipe salah satu dari [{1}]",
+		"maxdimension":     "{0} tidak boleh lebih besar dari {1} piksel",
+		"mindimension":     "{0} minimal berukuran {1} piksel",
+		"nik":              "{0} harus berupa NIK yang valid",
+		"npwp":             "{0} harus berupa NPWP yang valid",
+		"mobile_idn":       "{0} harus berupa nomor ponsel Indonesia yang valid",
+		"postcode_idn":     "{0} harus berupa kode pos Indonesia yang valid",
+		"plate_idn":        "{0} harus berupa nomor polisi kendaraan yang valid",
+		"bank_account_idn": "{0} harus berupa nomor rekening bank yang valid",
+		"json_syntax":      "{0} harus berupa JSON yang valid",
+		"field_type":       "{0} harus bertipe {1}",
+	},
 }
 
-type RegisterValidation func(v *validator.Validate) error
+// RegisterTranslation is a function for register the messages of custom tags, the translations are registered
+// into every locale
+func (vt *Validation) RegisterTranslation(translations ...RegisterTranslation) (err error) {
 
-func (vt *Validation) RegisterValidation(validations ...RegisterValidation) (err error) {
+	for _, locale := range vt.locales {
 
-	validatorJPG := func(fl validator.FieldLevel) bool {
-		charValidation := regexp.MustCompile(`^.(jpg|jpeg|JPG|JPEG)$`)
-		return charValidation.MatchString(fl.Field().String())
-	}
+		trans, _ := vt.uni.GetTranslator(locale)
 
-	err = vt.v.RegisterValidation("jpg", validatorJPG)
-	if err != nil {
-		return err
-	}
+		for tag, message := range customMessages[LocaleEnglish] {
+			if localeMessage, ok := customMessages[locale][tag]; ok {
+				message = localeMessage
+			}
 
-	validatorPNG := func(fl validator.FieldLevel) bool {
-		charValidation := regexp.MustCompile(`^.(png|PNG)$`)
-		return charValidation.MatchString(fl.Field().String())
-	}
+			if err = vt.registerMessage(trans, tag, message); err != nil {
+				return err
+			}
+		}
 
-	err = vt.v.RegisterValidation("png", validatorPNG)
-	if err != nil {
-		return err
+		for _, transFunc := range translations {
+			err = transFunc(vt.v, &trans)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	validatorPDF := func(fl validator.FieldLevel) bool {
-		charValidation := regexp.MustCompile(`^.(pdf|PDF)$`)
-		return charValidation.MatchString(fl.Field().String())
-	}
+	return nil
 
-	err = vt.v.RegisterValidation("pdf", validatorPDF)
-	if err != nil {
-		return err
-	}
+}
 
-	validatorImage := func(fl validator.FieldLevel) bool {
-		charValidation := regexp.MustCompile(`^.(jpg|jpeg|png|JPG|JPEG|PNG)$`)
-		return charValidation.MatchString(fl.Field().String())
+// registerMessage is a function for register the message of tag into the translator
+func (vt *Validation) registerMessage(trans ut.Translator, tag string, message string) error {
+
+	register := func(ut ut.Translator) error { return ut.Add(tag, message, true) }
+	translation := func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T(tag, fe.Field(), fe.Param())
+		return t
 	}
 
-	err = vt.v.RegisterValidation("image", validatorImage)
-	if err != nil {
-		return err
+	return vt.v.RegisterTranslation(tag, trans, register, translation)
+}
+
+type RegisterValidation func(v *validator.Validate) error
+
+// RegisterValidation is a function for register the custom tags (e.g. jpg, png, pdf, image, maxfilesize, mimetype,
+// maxdimension, mindimension, nik, npwp, mobile_idn, postcode_idn, plate_idn & bank_account_idn) and the validations of caller
+func (vt *Validation) RegisterValidation(validations ...RegisterValidation) (err error) {
+
+	// the file header is a struct, so it's converted for the file validators
+	vt.v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		header := field.Interface().(multipart.FileHeader)
+		return uploadedFile{&header}
+	}, multipart.FileHeader{})
+
+	for _, customValidations := range []map[string]validator.Func{fileValidations, indonesiaValidations} {
+		for tag, validateFunc := range customValidations {
+			err = vt.v.RegisterValidation(tag, validateFunc)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	for _, validateFunc := range validations {
@@ -0,0 +1,233 @@
+package goutil
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// uploadedFile is a value of multipart.FileHeader for the validators, the validator doesn't run the tags on the struct
+// so the file header is wrapped by the slice
+type uploadedFile []*multipart.FileHeader
+
+// fileContent is a content of file field (*multipart.FileHeader or []byte) which is validated
+type fileContent struct {
+	size int64
+	open func() (io.ReadCloser, error)
+}
+
+var (
+	extensionJPG   = regexp.MustCompile(`(?i)^.*\.(jpg|jpeg)$`)
+	extensionPNG   = regexp.MustCompile(`(?i)^.*\.png$`)
+	extensionPDF   = regexp.MustCompile(`(?i)^.*\.pdf$`)
+	extensionImage = regexp.MustCompile(`(?i)^.*\.(jpg|jpeg|png)$`)
+)
+
+var (
+	magicJPG = []byte{0xFF, 0xD8, 0xFF}
+	magicPNG = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+	magicPDF = []byte("%PDF-")
+)
+
+// fileValidations is the validators of file, the string field is validated by the extension of file name
+// and the file field (*multipart.FileHeader or []byte) is validated by the content
+//
+//	File *multipart.FileHeader `form:"file" validate:"required,image,maxfilesize=2MB,maxdimension=1920x1080"`
+var fileValidations = map[string]validator.Func{
+	"jpg":          fileValidation(extensionJPG, magicJPG),
+	"png":          fileValidation(extensionPNG, magicPNG),
+	"pdf":          fileValidation(extensionPDF, magicPDF),
+	"image":        fileValidation(extensionImage, magicJPG, magicPNG),
+	"maxfilesize":  validateMaxFileSize,
+	"mimetype":     validateMIMEType,
+	"maxdimension": validateDimension(func(width, height, maxWidth, maxHeight int) bool { return width <= maxWidth && height <= maxHeight }),
+	"mindimension": validateDimension(func(width, height, minWidth, minHeight int) bool { return width >= minWidth && height >= minHeight }),
+}
+
+// fileValidation is a function for create the validator which checks the extension of string or the magic bytes of file
+func fileValidation(extension *regexp.Regexp, magics ...[]byte) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+
+		if fl.Field().Kind() == reflect.String {
+			return extension.MatchString(fl.Field().String())
+		}
+
+		file, ok := fieldFile(fl.Field())
+		if !ok {
+			return false
+		}
+
+		header, err := file.head()
+		if err != nil {
+			return false
+		}
+
+		for _, magic := range magics {
+			if bytes.HasPrefix(header, magic) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// validateMaxFileSize is a function for validate the size of file, the param is the size with the unit (B, KB, MB or GB), e.g. maxfilesize=2MB
+func validateMaxFileSize(fl validator.FieldLevel) bool {
+
+	file, ok := fieldFile(fl.Field())
+	if !ok {
+		return false
+	}
+
+	return file.size <= parseFileSize(fl.Param())
+}
+
+// validateMIMEType is a function for validate the MIME type which is detected from the content of file,
+// the param is the allowed MIME types separated by the space, e.g. mimetype=image/jpeg image/png
+func validateMIMEType(fl validator.FieldLevel) bool {
+
+	file, ok := fieldFile(fl.Field())
+	if !ok {
+		return false
+	}
+
+	header, err := file.head()
+	if err != nil {
+		return false
+	}
+
+	// we don't need handle this error because the detected content type is always valid
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(header))
+	for _, allowed := range strings.Fields(fl.Param()) {
+		if strings.EqualFold(mimeType, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateDimension is a function for create the validator of image dimension, the param is the width & height, e.g. maxdimension=1920x1080
+func validateDimension(compare func(width, height, paramWidth, paramHeight int) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+
+		file, ok := fieldFile(fl.Field())
+		if !ok {
+			return false
+		}
+
+		reader, err := file.open()
+		if err != nil {
+			return false
+		}
+		defer reader.Close()
+
+		config, _, err := image.DecodeConfig(reader)
+		if err != nil {
+			return false
+		}
+
+		width, height := parseDimension(fl.Param())
+		return compare(config.Width, config.Height, width, height)
+	}
+}
+
+// fieldFile is a function for get the content of file field
+func fieldFile(field reflect.Value) (fileContent, bool) {
+
+	switch value := field.Interface().(type) {
+	case uploadedFile:
+		if len(value) == 0 || value[0] == nil {
+			return fileContent{}, false
+		}
+
+		header := value[0]
+		return fileContent{
+			size: header.Size,
+			open: func() (io.ReadCloser, error) { return header.Open() },
+		}, true
+
+	case []byte:
+		return fileContent{
+			size: int64(len(value)),
+			open: func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(value)), nil },
+		}, true
+	}
+
+	return fileContent{}, false
+}
+
+// head is a function for read the first 512 bytes of file, it's enough for sniff the content type
+func (f fileContent) head() ([]byte, error) {
+
+	reader, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(reader, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+
+	return header[:n], nil
+}
+
+// parseFileSize is a function for parse the size with the unit into bytes, it panics when the param is invalid like the param of validator
+func parseFileSize(param string) int64 {
+
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	param = strings.ToUpper(strings.TrimSpace(param))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if value, ok := strings.CutSuffix(param, unit.suffix); ok {
+			param, multiplier = strings.TrimSpace(value), unit.size
+			break
+		}
+	}
+
+	size, err := strconv.ParseFloat(param, 64)
+	if err != nil || size < 0 {
+		panic(fmt.Sprintf("Bad file size param %s", param))
+	}
+
+	return int64(size * float64(multiplier))
+}
+
+// parseDimension is a function for parse the dimension (e.g. 1920x1080) into the width & height
+func parseDimension(param string) (width, height int) {
+
+	w, h, ok := strings.Cut(strings.ToLower(param), "x")
+	width, errWidth := strconv.Atoi(strings.TrimSpace(w))
+	height, errHeight := strconv.Atoi(strings.TrimSpace(h))
+	if !ok || errWidth != nil || errHeight != nil {
+		panic(fmt.Sprintf("Bad dimension param %s", param))
+	}
+
+	return width, height
+}
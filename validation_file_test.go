@@ -0,0 +1,107 @@
+package goutil
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Avatar struct {
+	FileName string                `json:"file_name" validate:"image"`
+	File     *multipart.FileHeader `json:"file" validate:"required,image,mimetype=image/png image/jpeg,maxfilesize=1KB,maxdimension=20x20,mindimension=2x2"`
+	Document []byte                `json:"document" validate:"omitempty,pdf"`
+}
+
+func newPNG(t *testing.T, width, height int) []byte {
+
+	var buffer bytes.Buffer
+	require.NoError(t, png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height))))
+
+	return buffer.Bytes()
+}
+
+func newFileHeader(t *testing.T, fileName string, content []byte) *multipart.FileHeader {
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { form.RemoveAll() })
+
+	return form.File["file"][0]
+}
+
+// TestValidationFile how to run this process
+// go test -v -run=TestValidationFile
+func TestValidationFile(t *testing.T) {
+	validation, err := NewValidation()
+	require.NoError(t, err)
+	require.NoError(t, validation.RegisterValidation())
+	require.NoError(t, validation.RegisterTranslation())
+
+	t.Run("valid", func(t *testing.T) {
+		avatar := Avatar{
+			FileName: "photo.PNG",
+			File:     newFileHeader(t, "photo.png", newPNG(t, 10, 10)),
+			Document: []byte("%PDF-1.7\n"),
+		}
+
+		assert.Empty(t, errorMessages(validation.ValidationStruct(avatar)))
+	})
+
+	t.Run("renamed file", func(t *testing.T) {
+		avatar := Avatar{
+			FileName: "a.jpg.exe",
+			File:     newFileHeader(t, "photo.png", []byte("MZ this is an executable")),
+			Document: newPNG(t, 1, 1),
+		}
+
+		assert.Equal(t, map[string]string{
+			"file_name": "file_name must be a valid format",
+			"file":      "file must be a valid format",
+			"document":  "document must be a valid format",
+		}, errorMessages(validation.ValidationStruct(avatar)))
+	})
+
+	t.Run("dimension", func(t *testing.T) {
+		avatar := Avatar{FileName: "photo.jpeg", File: newFileHeader(t, "photo.png", newPNG(t, 30, 1))}
+
+		assert.Equal(t, map[string]string{
+			"file": "file must not be larger than 20x20 pixels",
+		}, errorMessages(validation.ValidationStruct(avatar)))
+
+		assert.Equal(t, map[string]string{
+			"file": "file tidak boleh lebih besar dari 20x20 piksel",
+		}, errorMessages(validation.ValidationStructLocale(avatar, LocaleIndonesia)))
+	})
+
+	t.Run("size", func(t *testing.T) {
+		content := append(newPNG(t, 2, 2), make([]byte, 2048)...)
+		avatar := Avatar{FileName: "photo.jpeg", File: newFileHeader(t, "photo.png", content)}
+
+		assert.Equal(t, map[string]string{
+			"file": "file must not be larger than 1KB",
+		}, errorMessages(validation.ValidationStruct(avatar)))
+	})
+}
+
+// TestParseFileSize how to run this process
+// go test -v -run=TestParseFileSize
+func TestParseFileSize(t *testing.T) {
+	assert.Equal(t, int64(512), parseFileSize("512"))
+	assert.Equal(t, int64(2048), parseFileSize("2KB"))
+	assert.Equal(t, int64(1536*1024), parseFileSize("1.5mb"))
+	assert.Panics(t, func() { parseFileSize("big") })
+}
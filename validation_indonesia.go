@@ -0,0 +1,149 @@
+package goutil
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// provinceCodes is the codes of province (Kemendagri) which are used by the first 2 digits of NIK
+var provinceCodes = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true, "21": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "36": true,
+	"51": true, "52": true, "53": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true,
+	"71": true, "72": true, "73": true, "74": true, "75": true, "76": true,
+	"81": true, "82": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true,
+}
+
+var (
+	regexMobileIDN      = regexp.MustCompile(`^(\+62|62|0)8[1-9][0-9]{6,10}$`)
+	regexPostcodeIDN    = regexp.MustCompile(`^[1-9][0-9]{4}$`)
+	regexPlateIDN       = regexp.MustCompile(`^[A-Z]{1,2} ?[1-9][0-9]{0,3} ?[A-Z]{0,3}$`)
+	regexBankAccountIDN = regexp.MustCompile(`^[0-9]{10,16}$`)
+)
+
+// indonesiaValidations is the validators of Indonesian identifiers, the field must be a string
+//
+//	NIK   string `json:"nik" validate:"required,nik"`
+//	Phone string `json:"phone" validate:"required,mobile_idn"`
+var indonesiaValidations = map[string]validator.Func{
+	"nik":              stringValidation(isNIK),
+	"npwp":             stringValidation(isNPWP),
+	"mobile_idn":       stringValidation(func(value string) bool { return regexMobileIDN.MatchString(stripSeparators(value)) }),
+	"postcode_idn":     stringValidation(regexPostcodeIDN.MatchString),
+	"plate_idn":        stringValidation(isPlate),
+	"bank_account_idn": stringValidation(func(value string) bool { return regexBankAccountIDN.MatchString(stripSeparators(value)) }),
+}
+
+// stringValidation is a function for create the validator of string field
+func stringValidation(validate func(value string) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+
+		if fl.Field().Kind() != reflect.String {
+			return false
+		}
+
+		return validate(fl.Field().String())
+	}
+}
+
+// isNIK is a function for validate the NIK (Nomor Induk Kependudukan), it checks the code of province & regency,
+// the birthdate (the day is added by 40 for female) and the serial number
+func isNIK(value string) bool {
+
+	if len(value) != 16 || !isDigits(value) {
+		return false
+	}
+
+	if !provinceCodes[value[0:2]] || value[2:4] == "00" || value[4:6] == "00" || value[12:16] == "0000" {
+		return false
+	}
+
+	day, _ := strconv.Atoi(value[6:8])
+	month, _ := strconv.Atoi(value[8:10])
+	year, _ := strconv.Atoi(value[10:12])
+
+	if day > 40 {
+		day -= 40
+	}
+
+	// the century isn't encoded, so the year 2000 is used for accept 29 February
+	date := time.Date(2000+year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return day > 0 && month > 0 && date.Day() == day && int(date.Month()) == month
+}
+
+// isNPWP is a function for validate the NPWP (Nomor Pokok Wajib Pajak), the format (e.g. 01.234.567.8-901.000) is allowed.
+// The 15 digits NPWP is validated by the check digit (Luhn) on the 9th digit, the 16 digits NPWP is
+// the NIK or the 15 digits NPWP with the prefix 0
+func isNPWP(value string) bool {
+
+	value = stripSeparators(value)
+	if !isDigits(value) {
+		return false
+	}
+
+	switch len(value) {
+	case 15:
+		return luhn(value[:9])
+	case 16:
+		if value[0] == '0' {
+			return luhn(value[1:10])
+		}
+		return isNIK(value)
+	}
+
+	return false
+}
+
+// luhn is a function for validate the digits by the Luhn algorithm, the last digit is the check digit
+func luhn(digits string) bool {
+
+	var sum int
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// isPlate is a function for validate the license plate (e.g. B 1234 ABC), the letters are case-insensitive
+func isPlate(value string) bool {
+	return regexPlateIDN.MatchString(strings.ToUpper(strings.Join(strings.Fields(value), " ")))
+}
+
+func isDigits(value string) bool {
+
+	if value == "" {
+		return false
+	}
+
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stripSeparators is a function for remove the separators of number (space, dot & dash)
+func stripSeparators(value string) string {
+	return strings.NewReplacer(" ", "", ".", "", "-", "").Replace(value)
+}
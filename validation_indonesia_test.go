@@ -0,0 +1,77 @@
+package goutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Citizen struct {
+	NIK         string `json:"nik" validate:"nik"`
+	NPWP        string `json:"npwp" validate:"npwp"`
+	Phone       string `json:"phone" validate:"mobile_idn"`
+	PostalCode  string `json:"postal_code" validate:"postcode_idn"`
+	Plate       string `json:"plate" validate:"plate_idn"`
+	BankAccount string `json:"bank_account" validate:"bank_account_idn"`
+}
+
+// TestIndonesiaValidation how to run this process
+// go test -v -run=TestIndonesiaValidation
+func TestIndonesiaValidation(t *testing.T) {
+	validation, err := NewValidation()
+	require.NoError(t, err)
+	require.NoError(t, validation.RegisterValidation())
+	require.NoError(t, validation.RegisterTranslation())
+
+	valid := Citizen{
+		NIK:         "3201014502900001",
+		NPWP:        "01.234.567.4-901.000",
+		Phone:       "+62 812-3456-7890",
+		PostalCode:  "40115",
+		Plate:       "b 1234 abc",
+		BankAccount: "123-456-7890",
+	}
+	assert.False(t, validation.ValidationStruct(valid).IsErrorExists())
+
+	invalid := Citizen{
+		NIK:         "3201013102900001",
+		NPWP:        "01.234.567.8-901.000",
+		Phone:       "021-5551234",
+		PostalCode:  "01234",
+		Plate:       "1234 B",
+		BankAccount: "12345",
+	}
+
+	assert.Equal(t, map[string]string{
+		"nik":          "nik harus berupa NIK yang valid",
+		"npwp":         "npwp harus berupa NPWP yang valid",
+		"phone":        "phone harus berupa nomor ponsel Indonesia yang valid",
+		"postal_code":  "postal_code harus berupa kode pos Indonesia yang valid",
+		"plate":        "plate harus berupa nomor polisi kendaraan yang valid",
+		"bank_account": "bank_account harus berupa nomor rekening bank yang valid",
+	}, errorMessages(validation.ValidationStructLocale(invalid, LocaleIndonesia)))
+}
+
+// TestIsNIK how to run this process
+// go test -v -run=TestIsNIK
+func TestIsNIK(t *testing.T) {
+	assert.True(t, isNIK("3201010101900001"))  // male, 1 January
+	assert.True(t, isNIK("3201016902000001"))  // female, 29 February
+	assert.False(t, isNIK("9901010101900001")) // unknown province
+	assert.False(t, isNIK("3200010101900001")) // unknown regency
+	assert.False(t, isNIK("3201013002900001")) // 30 February
+	assert.False(t, isNIK("3201010113900001")) // 13th month
+	assert.False(t, isNIK("3201010101900000")) // empty serial
+	assert.False(t, isNIK("320101010190000"))
+}
+
+// TestIsNPWP how to run this process
+// go test -v -run=TestIsNPWP
+func TestIsNPWP(t *testing.T) {
+	assert.True(t, isNPWP("012345674901000"))
+	assert.True(t, isNPWP("0012345674901000"))
+	assert.True(t, isNPWP("3201010101900001"))
+	assert.False(t, isNPWP("012345678901000"))
+	assert.False(t, isNPWP("01234567490100"))
+}
@@ -1,8 +1,11 @@
 package goutil
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 type User struct {
@@ -31,3 +34,93 @@ func TestValidation(t *testing.T) {
 		t.Log(string(errorInformations))
 	}
 }
+
+type Base struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type Address struct {
+	PostalCode string `json:"postal_code" validate:"required"`
+}
+
+type Item struct {
+	Name    string   `json:"name" validate:"required"`
+	Address *Address `json:"address" validate:"required"`
+}
+
+type Order struct {
+	Base
+	Items []Item              `json:"items" validate:"dive"`
+	Tags  []string            `json:"tags" validate:"dive,required"`
+	Notes map[string]*Address `json:"notes" validate:"dive"`
+}
+
+// TestValidationNested how to run this process
+// go test -v -run=TestValidationNested
+func TestValidationNested(t *testing.T) {
+	order := &Order{
+		Items: []Item{
+			{Name: "book", Address: &Address{PostalCode: "12345"}},
+			{Name: "pen", Address: &Address{}},
+		},
+		Tags:  []string{"a", ""},
+		Notes: map[string]*Address{"home": {}},
+	}
+
+	validationErrors := tValidation.ValidationStruct(order)
+
+	var fields []string
+	for _, err := range validationErrors.Errors {
+		fields = append(fields, err.Field)
+	}
+
+	assert.ElementsMatch(t, []string{"id", "items[1].address.postal_code", "tags[1]", "notes[home].postal_code"}, fields)
+	for _, err := range validationErrors.Errors {
+		if err.Field == "items[1].address.postal_code" {
+			assert.Equal(t, "postal_code is a required field", err.Message)
+		}
+	}
+}
+
+type Upload struct {
+	Name string `json:"name" validate:"required"`
+	File string `json:"file" validate:"jpg"`
+}
+
+// TestValidationLocale how to run this process
+// go test -v -run=TestValidationLocale
+func TestValidationLocale(t *testing.T) {
+	validation, err := NewValidation()
+	assert.NoError(t, err)
+	assert.NoError(t, validation.RegisterValidation())
+	assert.NoError(t, validation.RegisterTranslation())
+
+	upload := Upload{File: "photo.gif"}
+
+	assert.Equal(t, map[string]string{
+		"name": "name is a required field",
+		"file": "file must be a valid format",
+	}, errorMessages(validation.ValidationStruct(upload)))
+
+	assert.Equal(t, map[string]string{
+		"name": "name wajib diisi",
+		"file": "file harus berformat yang valid",
+	}, errorMessages(validation.ValidationStructLocale(upload, LocaleIndonesia)))
+
+	ctx := context.WithValue(context.Background(), KeyLanguage, "fr-FR,id-ID;q=0.9,en;q=0.8")
+	assert.Equal(t, "name wajib diisi", errorMessages(validation.ValidationStructContext(ctx, upload))["name"])
+
+	// the unregistered locale falls back to english
+	assert.Equal(t, "name is a required field", errorMessages(validation.ValidationStructLocale(upload, "fr"))["name"])
+}
+
+// errorMessages is a function for map the fields of validation errors into their messages
+func errorMessages(validationErrors ValidationErrors) map[string]string {
+
+	messages := map[string]string{}
+	for _, err := range validationErrors.Errors {
+		messages[err.Field] = err.Message
+	}
+
+	return messages
+}